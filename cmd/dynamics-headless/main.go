@@ -0,0 +1,214 @@
+// Command dynamics-headless runs a dynamics scene without a window, writing
+// the trajectories of the bodies and a summary of the run.
+//
+//	dynamics-headless -scene ../orbit-3d-opengl/scenes/vanilla-gravity.json -duration 10 -trajectory out.csv
+//
+// Scenes without a boundary are unbounded: bodies are not kept in any box
+// unless the scene sets one.
+//
+// Exit codes:
+//
+//	0   the run finished
+//	1   the scene or the trajectory file could not be read or written
+//	2   invalid command line
+//	3   the energy error exceeded -max-energy-error, or the bodies diverged
+//	130 the run was interrupted, after writing what was simulated so far
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/signal"
+
+	"github.com/rpagliuca/go-physics/pkg/dynamics"
+)
+
+const (
+	EXIT_OK          = 0
+	EXIT_ERROR       = 1
+	EXIT_USAGE       = 2
+	EXIT_INACCURATE  = 3
+	EXIT_INTERRUPTED = 130
+)
+
+type summary struct {
+	Steps          int     `json:"steps"`
+	Time           float64 `json:"time"`
+	InitialBodies  int     `json:"initialBodies"`
+	FinalBodies    int     `json:"finalBodies"`
+	InitialEnergy  float64 `json:"initialEnergy"`
+	FinalEnergy    float64 `json:"finalEnergy"`
+	EnergyError    float64 `json:"energyError"`
+	Collisions     int     `json:"collisions"`
+	Interrupted    bool    `json:"interrupted"`
+	NonFiniteState bool    `json:"nonFiniteState"`
+}
+
+func main() {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr, interrupt))
+}
+
+func run(args []string, stdout, stderr io.Writer, interrupt <-chan os.Signal) int {
+
+	flags := flag.NewFlagSet("dynamics-headless", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	sceneFile := flags.String("scene", "", "scene file, in JSON or YAML")
+	duration := flags.Float64("duration", 0, "simulated time to run")
+	steps := flags.Int("steps", 0, "number of steps to run, instead of -duration")
+	integratorName := flags.String("integrator", "", "integrator replacing the one of the scene, such as runge-kutta")
+	trajectoryFile := flags.String("trajectory", "", "file receiving the trajectories of the bodies")
+	format := flags.String("format", "csv", "format of the trajectories, csv or ndjson")
+	every := flags.Int("every", 1, "record the trajectories every this many steps")
+	energy := flags.Bool("energy", false, "add the energies to the trajectories")
+	jsonSummary := flags.Bool("json", false, "write the summary as JSON")
+	maxEnergyError := flags.Float64("max-energy-error", 0, "fail when the relative energy error is above this, if not zero")
+
+	if err := flags.Parse(args); err != nil {
+		return EXIT_USAGE
+	}
+	usage := func(message string) int {
+		fmt.Fprintln(stderr, message)
+		flags.Usage()
+		return EXIT_USAGE
+	}
+	if *sceneFile == "" {
+		return usage("-scene is required")
+	}
+	if (*duration > 0) == (*steps > 0) {
+		return usage("exactly one of -duration and -steps must be positive")
+	}
+	if *every < 1 {
+		return usage("-every must be positive")
+	}
+	trajectoryFormat := dynamics.CSVTrajectory
+	switch *format {
+	case "csv":
+	case "ndjson":
+		trajectoryFormat = dynamics.NDJSONTrajectory
+	default:
+		return usage("-format must be csv or ndjson")
+	}
+
+	f, err := os.Open(*sceneFile)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return EXIT_ERROR
+	}
+	state, err := dynamics.LoadScene(f)
+	f.Close()
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %s\n", *sceneFile, err)
+		return EXIT_ERROR
+	}
+	if *integratorName != "" {
+		integrator, err := dynamics.NewIntegrator(*integratorName)
+		if err != nil {
+			return usage(err.Error())
+		}
+		state.Settings.Integrator = integrator
+	}
+
+	var recorder *dynamics.TrajectoryRecorder
+	if *trajectoryFile != "" {
+		out, err := os.Create(*trajectoryFile)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return EXIT_ERROR
+		}
+		defer out.Close()
+		recorder = dynamics.NewTrajectoryRecorder(out, trajectoryFormat, *every)
+		recorder.Energy = *energy
+	}
+
+	if *steps == 0 {
+		*steps = int(math.Ceil(*duration/state.Settings.DeltaTime - 1e-9))
+	}
+
+	s := summary{
+		InitialBodies: len(state.Bodies),
+		InitialEnergy: state.TotalEnergy(),
+	}
+	record := func() error {
+		if recorder == nil {
+			return nil
+		}
+		return recorder.Record(state)
+	}
+
+	err = record()
+	for i := 0; i < *steps && err == nil; i++ {
+		select {
+		case <-interrupt:
+			s.Interrupted = true
+		default:
+		}
+		if s.Interrupted {
+			break
+		}
+		state = dynamics.UpdateState(state)
+		s.Collisions += len(state.Collisions)
+		err = record()
+	}
+	if recorder != nil {
+		if flushErr := recorder.Flush(); err == nil {
+			err = flushErr
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return EXIT_ERROR
+	}
+
+	s.Steps = state.Step
+	s.Time = state.Time
+	s.FinalBodies = len(state.Bodies)
+	s.FinalEnergy = state.TotalEnergy()
+	s.EnergyError = math.Abs(s.FinalEnergy - s.InitialEnergy)
+	if s.InitialEnergy != 0 {
+		s.EnergyError /= math.Abs(s.InitialEnergy)
+	}
+	s.NonFiniteState = math.IsNaN(s.FinalEnergy) || math.IsInf(s.FinalEnergy, 0)
+	writeSummary(stdout, s, *jsonSummary)
+
+	switch {
+	case s.Interrupted:
+		return EXIT_INTERRUPTED
+	case s.NonFiniteState:
+		return EXIT_INACCURATE
+	case *maxEnergyError > 0 && s.EnergyError > *maxEnergyError:
+		fmt.Fprintf(stderr, "energy error %g above %g\n", s.EnergyError, *maxEnergyError)
+		return EXIT_INACCURATE
+	}
+	return EXIT_OK
+}
+
+func writeSummary(w io.Writer, s summary, asJSON bool) {
+	if asJSON {
+		// JSON has no representation for the energy of diverged runs
+		if s.NonFiniteState {
+			s.FinalEnergy, s.EnergyError = 0, 0
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(s)
+		return
+	}
+	fmt.Fprintf(w, "steps: %d\n", s.Steps)
+	fmt.Fprintf(w, "time: %g\n", s.Time)
+	fmt.Fprintf(w, "bodies: %d -> %d\n", s.InitialBodies, s.FinalBodies)
+	fmt.Fprintf(w, "energy: %g -> %g\n", s.InitialEnergy, s.FinalEnergy)
+	fmt.Fprintf(w, "relative energy error: %g\n", s.EnergyError)
+	fmt.Fprintf(w, "collisions: %d\n", s.Collisions)
+	if s.NonFiniteState {
+		fmt.Fprintln(w, "the bodies diverged")
+	}
+	if s.Interrupted {
+		fmt.Fprintln(w, "interrupted")
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const ORBIT_SCENE = `
+settings:
+  deltaTime: 0.01
+  integrator: {type: euler}
+  boundary: {type: unbounded}
+bodies:
+  - {x: 1, y: 0, vx: 0, vy: 1, radius: 0.01}
+gravitySources:
+  - {type: point, x: 0, y: 0, gm: 1}
+`
+
+const COLLISION_SCENE = `
+settings:
+  deltaTime: 0.1
+  boundary: {type: unbounded}
+  collisionResponse: elastic
+bodies:
+  - {x: 0, y: 0, vx: 1, vy: 0, radius: 1}
+  - {x: 5, y: 0, vx: -1, vy: 0, radius: 1}
+`
+
+// withScene runs the test with the scene written to a temporary directory,
+// which also receives the trajectories
+func withScene(t *testing.T, scene string, test func(dir, path string)) {
+	dir, err := ioutil.TempDir("", "dynamics-headless")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "scene.yaml")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(scene), 0644))
+	test(dir, path)
+}
+
+func runSummary(t *testing.T, args ...string) (int, summary, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(append(args, "-json"), &stdout, &stderr, make(chan os.Signal))
+	var s summary
+	if stdout.Len() > 0 {
+		assert.NoError(t, json.Unmarshal(stdout.Bytes(), &s))
+	}
+	return code, s, stderr.String()
+}
+
+func TestRun(t *testing.T) {
+
+	withScene(t, ORBIT_SCENE, func(dir, path string) {
+		trajectory := filepath.Join(dir, "out.csv")
+		var stdout, stderr bytes.Buffer
+
+		code := run([]string{"-scene", path, "-steps", "10", "-trajectory", trajectory}, &stdout, &stderr, make(chan os.Signal))
+
+		assert.Equal(t, EXIT_OK, code)
+		assert.Empty(t, stderr.String())
+		assert.Contains(t, stdout.String(), "steps: 10\n")
+		assert.Contains(t, stdout.String(), "relative energy error: ")
+		assert.Contains(t, stdout.String(), "collisions: 0\n")
+		content, err := ioutil.ReadFile(trajectory)
+		assert.NoError(t, err)
+		// The header, the initial state and one line per step
+		assert.Len(t, strings.Split(strings.TrimSpace(string(content)), "\n"), 12)
+	})
+}
+
+func TestRunCountsCollisions(t *testing.T) {
+
+	withScene(t, COLLISION_SCENE, func(dir, path string) {
+		code, s, _ := runSummary(t, "-scene", path, "-duration", "3")
+
+		assert.Equal(t, EXIT_OK, code)
+		assert.Equal(t, 1, s.Collisions)
+		assert.Equal(t, 30, s.Steps)
+		assert.Equal(t, 2, s.FinalBodies)
+		assert.InDelta(t, 0.0, s.EnergyError, 1e-12)
+	})
+}
+
+func TestRunIntegratorOverride(t *testing.T) {
+
+	withScene(t, ORBIT_SCENE, func(dir, path string) {
+		_, euler, _ := runSummary(t, "-scene", path, "-steps", "1000")
+		_, yoshida, _ := runSummary(t, "-scene", path, "-steps", "1000", "-integrator", "yoshida")
+
+		// Euler gains energy along the orbit
+		assert.Greater(t, euler.EnergyError, 1e-2)
+		assert.Less(t, yoshida.EnergyError, 1e-8)
+	})
+}
+
+func TestRunMaxEnergyError(t *testing.T) {
+
+	withScene(t, ORBIT_SCENE, func(dir, path string) {
+		code, s, stderr := runSummary(t, "-scene", path, "-steps", "1000", "-max-energy-error", "1e-3")
+
+		assert.Equal(t, EXIT_INACCURATE, code)
+		assert.Contains(t, stderr, "energy error")
+		assert.Greater(t, s.EnergyError, 1e-3)
+	})
+}
+
+func TestRunErrors(t *testing.T) {
+
+	withScene(t, ORBIT_SCENE, func(dir, path string) {
+		for _, test := range []struct {
+			args []string
+			code int
+		}{
+			{[]string{"-steps", "10"}, EXIT_USAGE},
+			{[]string{"-scene", path}, EXIT_USAGE},
+			{[]string{"-scene", path, "-steps", "10", "-duration", "1"}, EXIT_USAGE},
+			{[]string{"-scene", path, "-steps", "10", "-format", "xml"}, EXIT_USAGE},
+			{[]string{"-scene", path, "-steps", "10", "-integrator", "magic"}, EXIT_USAGE},
+			{[]string{"-scene", path, "-unknown"}, EXIT_USAGE},
+			{[]string{"-scene", filepath.Join(dir, "missing.yaml"), "-steps", "10"}, EXIT_ERROR},
+			{[]string{"-scene", path, "-steps", "10", "-trajectory", filepath.Join(dir, "missing", "out.csv")}, EXIT_ERROR},
+		} {
+			var stdout, stderr bytes.Buffer
+			assert.Equal(t, test.code, run(test.args, &stdout, &stderr, make(chan os.Signal)), test.args)
+			assert.NotEmpty(t, stderr.String(), test.args)
+		}
+	})
+
+	withScene(t, "bodies: [", func(dir, path string) {
+		var stdout, stderr bytes.Buffer
+		assert.Equal(t, EXIT_ERROR, run([]string{"-scene", path, "-steps", "10"}, &stdout, &stderr, make(chan os.Signal)))
+	})
+}
+
+func TestRunInterrupted(t *testing.T) {
+
+	withScene(t, ORBIT_SCENE, func(dir, path string) {
+		trajectory := filepath.Join(dir, "out.csv")
+		interrupt := make(chan os.Signal)
+		go func() { interrupt <- os.Interrupt }()
+		var stdout, stderr bytes.Buffer
+
+		code := run([]string{"-scene", path, "-steps", "100000000", "-trajectory", trajectory, "-json"}, &stdout, &stderr, interrupt)
+
+		assert.Equal(t, EXIT_INTERRUPTED, code)
+		var s summary
+		assert.NoError(t, json.Unmarshal(stdout.Bytes(), &s))
+		assert.True(t, s.Interrupted)
+		assert.Less(t, s.Steps, 100000000)
+		// Every simulated step reached the file
+		content, err := ioutil.ReadFile(trajectory)
+		assert.NoError(t, err)
+		assert.Len(t, strings.Split(strings.TrimSpace(string(content)), "\n"), s.Steps+2)
+	})
+}
@@ -6,7 +6,10 @@ http://www.learnopengl.com/#!Lighting/Materials
 Shows basic materials with phong lighting
 */
 import (
+	"flag"
 	"log"
+	"math"
+	"os"
 	"runtime"
 
 	"github.com/cstegel/opengl-samples-golang/light-maps/cam"
@@ -15,7 +18,6 @@ import (
 	"github.com/go-gl/gl/v4.1-core/gl"
 	"github.com/go-gl/glfw/v3.1/glfw"
 	"github.com/go-gl/mathgl/mgl32"
-	"github.com/rpagliuca/go-physics/pkg/algebra"
 	"github.com/rpagliuca/go-physics/pkg/dynamics"
 )
 
@@ -70,7 +72,11 @@ func init() {
 	runtime.LockOSThread()
 }
 
+var sceneFile = flag.String("scene", "scenes/multi-yin-yang.json", "scene file to simulate, from the scenes directory")
+
 func main() {
+	flag.Parse()
+
 	if err := glfw.Init(); err != nil {
 		log.Fatalln("failed to inifitialize glfw:", err)
 	}
@@ -175,8 +181,15 @@ func programLoop(window *win.Window) error {
 
 	camera := cam.NewFpsCamera(mgl32.Vec3{20, 60, -40}, mgl32.Vec3{0, 1, 0}, 80, -30, window.InputManager())
 
-	//state := vanillaGravity
-	state := multiYinYang
+	f, err := os.Open(*sceneFile)
+	if err != nil {
+		return err
+	}
+	state, err := dynamics.LoadScene(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
 
 	for !window.ShouldClose() {
 
@@ -252,18 +265,20 @@ func programLoop(window *win.Window) error {
 
 		state = dynamics.UpdateState(state)
 
+		// x, y, z and cube side, one unit of the scene being one metre
 		bodyPositions := [][]float32{}
 		for _, body := range state.Bodies {
-			bodyPositions = append(bodyPositions, []float32{float32(body.X) / 10.0, float32(body.Y) / 10.0, 0.0})
+			side := float32(2 * body.GetRadius(state.Settings))
+			bodyPositions = append(bodyPositions, []float32{float32(body.X), float32(body.Y), float32(body.Z), side})
 		}
 		for _, gravitySources := range state.GravitySources {
-			bodyPositions = append(bodyPositions, []float32{float32(gravitySources.GetX() / 10.0), float32(gravitySources.GetY() / 10.0), 0.0})
+			bodyPositions = append(bodyPositions, []float32{float32(gravitySources.GetX()), float32(gravitySources.GetY()), float32(gravitySources.GetZ()), 1.0})
 		}
 
 		for _, pos := range bodyPositions {
 
 			// turn the cubes into rectangular prisms for more fun
-			worldTranslate := mgl32.Translate3D(pos[0], pos[1], pos[2])
+			worldTranslate := mgl32.Translate3D(pos[0], pos[1], pos[2]).Mul4(mgl32.Scale3D(pos[3], pos[3], pos[3]))
 			_ = worldTranslate.Mul4(
 				rotateX.Mul3(rotateY).Mul3(rotateZ).Mat4(),
 			)
@@ -291,50 +306,3 @@ func programLoop(window *win.Window) error {
 
 	return nil
 }
-
-const PIXELS_PER_METER = 10.0
-const FRAME_RATE = 60.0
-const BOX_SIZE = 10.0
-const LINE_WIDTH = 10.0
-const SCREEN_WIDTH = 500.0
-const SCREEN_HEIGHT = 500.0
-
-var SETTINGS = dynamics.Settings{
-	ViewportWidth:       SCREEN_WIDTH,
-	ViewportHeight:      SCREEN_HEIGHT,
-	ViewportBoxSize:     BOX_SIZE,
-	GravityAcceleration: 9.8 * PIXELS_PER_METER,
-	DeltaTime:           1.0 / FRAME_RATE,
-}
-
-var multiYinYang = dynamics.State{
-	SETTINGS,
-	[]dynamics.BodyState{
-		{X: SCREEN_WIDTH / 2.0, Y: SCREEN_HEIGHT / 20.0 * 1.0, VX: 15.0 * PIXELS_PER_METER, VY: 0.0},
-		{X: SCREEN_WIDTH / 2.0, Y: SCREEN_HEIGHT / 20.0 * 3.0, VX: 15.0 * PIXELS_PER_METER, VY: 0.0},
-		{X: SCREEN_WIDTH / 2.0, Y: SCREEN_HEIGHT / 20.0 * 5.0, VX: 15.0 * PIXELS_PER_METER, VY: 0.0},
-		{X: SCREEN_WIDTH / 2.0, Y: SCREEN_HEIGHT / 20.0 * 7.0, VX: 15.0 * PIXELS_PER_METER, VY: 0.0},
-		{X: SCREEN_WIDTH / 2.0, Y: SCREEN_HEIGHT / 20.0 * 13.0, VX: -15.0 * PIXELS_PER_METER, VY: 0.0},
-		{X: SCREEN_WIDTH / 2.0, Y: SCREEN_HEIGHT / 20.0 * 15.0, VX: -15.0 * PIXELS_PER_METER, VY: 0.0},
-		{X: SCREEN_WIDTH / 2.0, Y: SCREEN_HEIGHT / 20.0 * 17.0, VX: -15.0 * PIXELS_PER_METER, VY: 0.0},
-		{X: SCREEN_WIDTH / 2.0, Y: SCREEN_HEIGHT / 20.0 * 19.0, VX: -15.0 * PIXELS_PER_METER, VY: 0.0},
-	},
-	[]dynamics.GravitySource{
-		// Fonte gravitacional pontual, como se fosse um movimento astronômico
-		&dynamics.PointGravitySource{SETTINGS, algebra.Point{SCREEN_WIDTH / 2.0, SCREEN_HEIGHT / 2.0}},
-	},
-}
-
-var vanillaGravity = dynamics.State{
-	SETTINGS,
-	[]dynamics.BodyState{
-		// 3 corpos
-		{X: 0.0, Y: 0.0, VX: 15.0 * PIXELS_PER_METER, VY: 3.0 * PIXELS_PER_METER},
-		{X: SCREEN_WIDTH / 2.0, Y: 80.0, VX: -40.0 * PIXELS_PER_METER, VY: -1.0 * PIXELS_PER_METER},
-		{X: SCREEN_WIDTH / 2.0, Y: 200.0, VX: -30.0 * PIXELS_PER_METER, VY: -7.0 * PIXELS_PER_METER},
-	},
-	[]dynamics.GravitySource{
-		// 1 fonte gravitacional no chão (gravidade padrão, como estamos acostumados)
-		&dynamics.LinearGravitySource{SETTINGS, algebra.Line{0.0, SCREEN_HEIGHT, SCREEN_WIDTH, SCREEN_HEIGHT}}, // Bottom
-	},
-}
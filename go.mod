@@ -17,4 +17,5 @@ require (
 	github.com/rpagliuca/go-gl-helpers v0.0.1
 	github.com/stretchr/testify v1.7.0
 	golang.org/x/image v0.0.0-20210220032944-ac19c3e999fb // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c
 )
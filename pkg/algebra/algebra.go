@@ -70,6 +70,25 @@ func (l Line) CalculateY(x float64) float64 {
 	return x*l.CoefficientA() + l.CoefficientB()
 }
 
+// Distance returns the distance from the point to the infinite line
+func (l Line) Distance(p Point) float64 {
+	cross := (l.X1-l.X0)*(p.Y-l.Y0) - (l.Y1-l.Y0)*(p.X-l.X0)
+	return math.Abs(cross) / l.Length()
+}
+
+// ClosestPoint returns the point of the segment, between the two ends of the
+// line, closest to the point
+func (l Line) ClosestPoint(p Point) Point {
+	dx, dy := l.X1-l.X0, l.Y1-l.Y0
+	length2 := dx*dx + dy*dy
+	if length2 == 0 {
+		return Point{l.X0, l.Y0}
+	}
+	t := ((p.X-l.X0)*dx + (p.Y-l.Y0)*dy) / length2
+	t = math.Max(0, math.Min(1, t))
+	return Point{l.X0 + t*dx, l.Y0 + t*dy}
+}
+
 func (l Line) Length() float64 {
 	return math.Pow(math.Pow(l.X1-l.X0, 2)+math.Pow(l.Y1-l.Y0, 2), 0.5)
 }
@@ -77,3 +96,46 @@ func (l Line) Length() float64 {
 type Point struct {
 	X, Y float64
 }
+
+type Point3D struct {
+	X, Y, Z float64
+}
+
+func (p Point3D) Length() float64 {
+	return math.Sqrt(p.X*p.X + p.Y*p.Y + p.Z*p.Z)
+}
+
+func (p Point3D) Normalize() Point3D {
+	length := p.Length()
+	return Point3D{p.X / length, p.Y / length, p.Z / length}
+}
+
+// Plane going through Point, perpendicular to Normal
+type Plane struct {
+	Point  Point3D
+	Normal Point3D
+}
+
+// SignedDistance is positive on the side the normal points to
+func (p Plane) SignedDistance(q Point3D) float64 {
+	n := p.Normal.Normalize()
+	return (q.X-p.Point.X)*n.X + (q.Y-p.Point.Y)*n.Y + (q.Z-p.Point.Z)*n.Z
+}
+
+func (p Plane) Distance(q Point3D) float64 {
+	return math.Abs(p.SignedDistance(q))
+}
+
+// PlanePerpendicularDecomposition returns the unit vector going from the point
+// straight towards the plane
+func PlanePerpendicularDecomposition(plane Plane, point Point3D) (Point3D, error) {
+	distance := plane.SignedDistance(point)
+	if distance == 0 || math.IsNaN(distance) {
+		return Point3D{}, errors.New("Failed calculating perpendicular vector from point to plane. Perhaps the point is on the plane.")
+	}
+	n := plane.Normal.Normalize()
+	if distance > 0 {
+		return Point3D{-n.X, -n.Y, -n.Z}, nil
+	}
+	return n, nil
+}
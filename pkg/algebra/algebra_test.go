@@ -1,8 +1,10 @@
 package algebra
 
 import (
-	"github.com/stretchr/testify/assert"
+	"math"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestHorizontalLinePerpendicularDecomposition(t *testing.T) {
@@ -131,3 +133,66 @@ func TestOverlappingLineAndPoint(t *testing.T) {
 		assert.NotNil(t, err)
 	}
 }
+
+func TestDistanceToLine(t *testing.T) {
+	assert.Equal(t, 2.0, Line{0, 0, 1, 0}.Distance(Point{5, -2}))
+	assert.Equal(t, 3.0, Line{1, 1, 1, 2}.Distance(Point{4, 10}))
+	assert.InDelta(t, math.Sqrt2, Line{0, 0, 1, 1}.Distance(Point{0, 2}), 1e-12)
+	assert.Equal(t, 0.0, Line{0, 0, 1, 1}.Distance(Point{3, 3}))
+}
+
+func TestPlanePerpendicularDecomposition(t *testing.T) {
+	floor := Plane{Point3D{0, 0, 1}, Point3D{0, 0, 2}}
+
+	got, err := PlanePerpendicularDecomposition(floor, Point3D{3, 4, 5})
+	assert.Nil(t, err)
+	assert.Equal(t, Point3D{0, 0, -1}, got)
+	assert.Equal(t, 4.0, floor.Distance(Point3D{3, 4, 5}))
+
+	got, err = PlanePerpendicularDecomposition(floor, Point3D{3, 4, -1})
+	assert.Nil(t, err)
+	assert.Equal(t, Point3D{0, 0, 1}, got)
+	assert.Equal(t, -2.0, floor.SignedDistance(Point3D{3, 4, -1}))
+
+	got, err = PlanePerpendicularDecomposition(floor, Point3D{3, 4, 1})
+	assert.Equal(t, Point3D{}, got)
+	assert.NotNil(t, err)
+
+	diagonal := Plane{Point3D{}, Point3D{1, 1, 1}}
+	assert.InDelta(t, math.Sqrt(3), diagonal.Distance(Point3D{1, 1, 1}), 1e-12)
+}
+
+func TestLineClosestPoint(t *testing.T) {
+	segment := Line{0, 0, 10, 0}
+
+	assert.Equal(t, Point{3, 0}, segment.ClosestPoint(Point{3, 5}))
+	// Past the ends of the segment
+	assert.Equal(t, Point{0, 0}, segment.ClosestPoint(Point{-4, -1}))
+	assert.Equal(t, Point{10, 0}, segment.ClosestPoint(Point{12, 3}))
+	assert.Equal(t, Point{1, 1}, Line{1, 1, 1, 1}.ClosestPoint(Point{5, 5}))
+}
+
+func TestPolygon(t *testing.T) {
+	// Unit square, counterclockwise, and an L shape, clockwise
+	square := Polygon{[]Point{{0, 0}, {1, 0}, {1, 1}, {0, 1}}}
+	l := Polygon{[]Point{{0, 0}, {0, 2}, {1, 2}, {1, 1}, {2, 1}, {2, 0}}}
+
+	assert.Equal(t, 1.0, square.SignedArea())
+	assert.Equal(t, -3.0, l.SignedArea())
+	assert.Equal(t, 3.0, l.Area())
+	assert.Equal(t, Point{0.5, 0.5}, square.Centroid())
+	c := l.Centroid()
+	assert.InDelta(t, 5.0/6, c.X, 1e-12)
+	assert.InDelta(t, 5.0/6, c.Y, 1e-12)
+
+	assert.True(t, l.Contains(Point{0.5, 1.5}))
+	assert.False(t, l.Contains(Point{1.5, 1.5}))
+	assert.False(t, l.Contains(Point{-1, 0.5}))
+
+	assert.Equal(t, Point{1, 1.5}, l.ClosestPoint(Point{1.5, 1.5}))
+	assert.Equal(t, Point{2, 0.5}, l.ClosestPoint(Point{3, 0.5}))
+
+	moved := square.Translate(2, 3)
+	assert.Equal(t, Point{2, 3}, moved.Vertices[0])
+	assert.Equal(t, Point{0, 0}, square.Vertices[0])
+}
@@ -0,0 +1,85 @@
+package algebra
+
+import "math"
+
+// Polygon is closed, its last vertex being joined to the first one
+type Polygon struct {
+	Vertices []Point
+}
+
+// Edges returns the sides of the polygon, following the order of the vertices
+func (p Polygon) Edges() []Line {
+	edges := make([]Line, len(p.Vertices))
+	for i, v := range p.Vertices {
+		next := p.Vertices[(i+1)%len(p.Vertices)]
+		edges[i] = Line{v.X, v.Y, next.X, next.Y}
+	}
+	return edges
+}
+
+// SignedArea is positive when the vertices turn counterclockwise, with the Y
+// axis pointing up
+func (p Polygon) SignedArea() float64 {
+	area := 0.0
+	for _, e := range p.Edges() {
+		area += e.X0*e.Y1 - e.X1*e.Y0
+	}
+	return area / 2
+}
+
+func (p Polygon) Area() float64 {
+	return math.Abs(p.SignedArea())
+}
+
+// Centroid returns the centre of mass of the area of the polygon
+func (p Polygon) Centroid() Point {
+	area := p.SignedArea()
+	if area == 0 {
+		return Point{}
+	}
+	x, y := 0.0, 0.0
+	for _, e := range p.Edges() {
+		cross := e.X0*e.Y1 - e.X1*e.Y0
+		x += (e.X0 + e.X1) * cross
+		y += (e.Y0 + e.Y1) * cross
+	}
+	return Point{x / (6 * area), y / (6 * area)}
+}
+
+// Contains tells whether the point is inside the polygon, with the even-odd
+// rule for polygons crossing themselves
+func (p Polygon) Contains(q Point) bool {
+	inside := false
+	for _, e := range p.Edges() {
+		if (e.Y0 > q.Y) != (e.Y1 > q.Y) {
+			x := e.X0 + (q.Y-e.Y0)*(e.X1-e.X0)/(e.Y1-e.Y0)
+			if q.X < x {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// ClosestPoint returns the point of the outline of the polygon closest to the
+// point
+func (p Polygon) ClosestPoint(q Point) Point {
+	closest := Point{math.Inf(1), math.Inf(1)}
+	distance := math.Inf(1)
+	for _, e := range p.Edges() {
+		c := e.ClosestPoint(q)
+		if d := math.Hypot(c.X-q.X, c.Y-q.Y); d < distance {
+			closest, distance = c, d
+		}
+	}
+	return closest
+}
+
+// Translate returns a copy of the polygon moved by dx and dy
+func (p Polygon) Translate(dx, dy float64) Polygon {
+	vertices := make([]Point, len(p.Vertices))
+	for i, v := range p.Vertices {
+		vertices[i] = Point{v.X + dx, v.Y + dy}
+	}
+	return Polygon{vertices}
+}
@@ -0,0 +1,151 @@
+// Package checkpoint implements the versioned binary format used to save and
+// restore simulations. A checkpoint starts with MAGIC, the format version and
+// the kind of simulation, followed by little-endian values. Floats are stored
+// bit by bit, so that a restored simulation continues exactly as the original.
+package checkpoint
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+const MAGIC = "GOPHYSCK"
+const VERSION = 1
+
+// MAX_STRING_LENGTH guards against corrupted lengths
+const MAX_STRING_LENGTH = 1 << 20
+
+// Writer writes the values of a checkpoint. Errors are sticky: after the
+// first one nothing else is written, and it is returned by Flush.
+type Writer struct {
+	w   *bufio.Writer
+	err error
+}
+
+func NewWriter(w io.Writer, kind string) *Writer {
+	writer := &Writer{w: bufio.NewWriter(w)}
+	_, writer.err = writer.w.WriteString(MAGIC)
+	writer.WriteInt(VERSION)
+	writer.WriteString(kind)
+	return writer
+}
+
+func (w *Writer) write(value interface{}) {
+	if w.err != nil {
+		return
+	}
+	w.err = binary.Write(w.w, binary.LittleEndian, value)
+}
+
+func (w *Writer) WriteFloat64(value float64) {
+	w.write(math.Float64bits(value))
+}
+
+func (w *Writer) WriteInt(value int) {
+	w.write(int64(value))
+}
+
+func (w *Writer) WriteBool(value bool) {
+	w.write(value)
+}
+
+func (w *Writer) WriteString(value string) {
+	w.WriteInt(len(value))
+	if w.err != nil {
+		return
+	}
+	_, w.err = w.w.WriteString(value)
+}
+
+func (w *Writer) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	return w.w.Flush()
+}
+
+// Reader reads the values of a checkpoint, with sticky errors like Writer
+type Reader struct {
+	r       io.Reader
+	err     error
+	Version int
+}
+
+// NewReader checks the header of the checkpoint, failing when it is not of
+// the given kind or was written by a newer version of the format
+func NewReader(r io.Reader, kind string) (*Reader, error) {
+	reader := &Reader{r: r}
+	magic := make([]byte, len(MAGIC))
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic) != MAGIC {
+		return nil, fmt.Errorf("not a checkpoint")
+	}
+	reader.Version = reader.ReadInt()
+	actualKind := reader.ReadString()
+	if reader.err != nil {
+		return nil, reader.err
+	}
+	if reader.Version < 1 || reader.Version > VERSION {
+		return nil, fmt.Errorf("unsupported checkpoint version %d", reader.Version)
+	}
+	if actualKind != kind {
+		return nil, fmt.Errorf("checkpoint of %s, expected %s", actualKind, kind)
+	}
+	return reader, nil
+}
+
+func (r *Reader) read(value interface{}) {
+	if r.err != nil {
+		return
+	}
+	r.err = binary.Read(r.r, binary.LittleEndian, value)
+	if r.err == io.EOF {
+		r.err = io.ErrUnexpectedEOF
+	}
+}
+
+func (r *Reader) ReadFloat64() float64 {
+	var bits uint64
+	r.read(&bits)
+	return math.Float64frombits(bits)
+}
+
+func (r *Reader) ReadInt() int {
+	var value int64
+	r.read(&value)
+	return int(value)
+}
+
+func (r *Reader) ReadBool() bool {
+	var value bool
+	r.read(&value)
+	return value
+}
+
+func (r *Reader) ReadString() string {
+	length := r.ReadLength(MAX_STRING_LENGTH)
+	if r.err != nil {
+		return ""
+	}
+	value := make([]byte, length)
+	r.read(value)
+	return string(value)
+}
+
+// ReadLength reads a length written with WriteInt, failing when it is
+// negative or above max
+func (r *Reader) ReadLength(max int) int {
+	length := r.ReadInt()
+	if r.err == nil && (length < 0 || length > max) {
+		r.err = fmt.Errorf("invalid length %d", length)
+		return 0
+	}
+	return length
+}
+
+// Err returns the first error found while reading
+func (r *Reader) Err() error {
+	return r.err
+}
@@ -0,0 +1,68 @@
+package checkpoint
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoundTrip(t *testing.T) {
+
+	var buffer bytes.Buffer
+	w := NewWriter(&buffer, "test")
+	w.WriteFloat64(math.Pi)
+	w.WriteFloat64(math.Copysign(0, -1))
+	w.WriteFloat64(math.Inf(1))
+	w.WriteInt(-42)
+	w.WriteBool(true)
+	w.WriteString("body")
+	assert.NoError(t, w.Flush())
+
+	r, err := NewReader(&buffer, "test")
+	assert.NoError(t, err)
+	assert.Equal(t, VERSION, r.Version)
+	assert.Equal(t, math.Pi, r.ReadFloat64())
+	assert.True(t, math.Signbit(r.ReadFloat64()))
+	assert.True(t, math.IsInf(r.ReadFloat64(), 1))
+	assert.Equal(t, -42, r.ReadInt())
+	assert.True(t, r.ReadBool())
+	assert.Equal(t, "body", r.ReadString())
+	assert.NoError(t, r.Err())
+
+	r.ReadFloat64()
+	assert.Error(t, r.Err())
+}
+
+func TestInvalidHeaders(t *testing.T) {
+
+	_, err := NewReader(bytes.NewBufferString("not a checkpoint"), "test")
+	assert.EqualError(t, err, "not a checkpoint")
+
+	var buffer bytes.Buffer
+	assert.NoError(t, NewWriter(&buffer, "other").Flush())
+	_, err = NewReader(&buffer, "test")
+	assert.EqualError(t, err, "checkpoint of other, expected test")
+
+	buffer.Reset()
+	buffer.WriteString(MAGIC)
+	buffer.Write([]byte{VERSION + 1, 0, 0, 0, 0, 0, 0, 0})
+	buffer.Write([]byte{4, 0, 0, 0, 0, 0, 0, 0})
+	buffer.WriteString("test")
+	_, err = NewReader(&buffer, "test")
+	assert.EqualError(t, err, "unsupported checkpoint version 2")
+}
+
+func TestCorruptedLength(t *testing.T) {
+
+	var buffer bytes.Buffer
+	w := NewWriter(&buffer, "test")
+	w.WriteInt(-1)
+	assert.NoError(t, w.Flush())
+
+	r, err := NewReader(&buffer, "test")
+	assert.NoError(t, err)
+	assert.Equal(t, "", r.ReadString())
+	assert.EqualError(t, r.Err(), "invalid length -1")
+}
@@ -0,0 +1,175 @@
+package dynamics
+
+import "math"
+
+const MAX_TREE_DEPTH = 48
+
+// octree is a node of the Barnes-Hut tree, covering the cube starting at
+// (X, Y, Z). Leaves keep the indices of their bodies.
+type octree struct {
+	X, Y, Z, Size float64
+	// Total mass and centre of mass of the bodies inside the node
+	Mass, CX, CY, CZ float64
+	Bodies           []int
+	Children         *[8]octree
+}
+
+func newOctree(bodies []BodyState) *octree {
+	minX, minY, minZ := math.Inf(1), math.Inf(1), math.Inf(1)
+	maxX, maxY, maxZ := math.Inf(-1), math.Inf(-1), math.Inf(-1)
+	for _, b := range bodies {
+		minX, maxX = math.Min(minX, b.X), math.Max(maxX, b.X)
+		minY, maxY = math.Min(minY, b.Y), math.Max(maxY, b.Y)
+		minZ, maxZ = math.Min(minZ, b.Z), math.Max(maxZ, b.Z)
+	}
+	size := math.Max(maxX-minX, math.Max(maxY-minY, maxZ-minZ))
+	if size == 0 {
+		size = 1
+	}
+	// Slightly larger, so that the bodies on the far edges are inside
+	tree := octree{X: minX, Y: minY, Z: minZ, Size: size * (1 + 1e-9)}
+	for i := range bodies {
+		tree.insert(bodies, i, 0)
+	}
+	tree.summarize(bodies)
+	return &tree
+}
+
+func (o *octree) insert(bodies []BodyState, i int, depth int) {
+	if o.Children == nil {
+		o.Bodies = append(o.Bodies, i)
+		if len(o.Bodies) == 1 || depth >= MAX_TREE_DEPTH {
+			return
+		}
+		half := o.Size / 2
+		o.Children = &[8]octree{}
+		for c := range o.Children {
+			o.Children[c] = octree{
+				X:    o.X + half*float64(c&1),
+				Y:    o.Y + half*float64(c>>1&1),
+				Z:    o.Z + half*float64(c>>2&1),
+				Size: half,
+			}
+		}
+		previous := o.Bodies
+		o.Bodies = nil
+		for _, j := range previous {
+			o.getChild(bodies[j]).insert(bodies, j, depth+1)
+		}
+		return
+	}
+	o.getChild(bodies[i]).insert(bodies, i, depth+1)
+}
+
+func (o *octree) getChild(b BodyState) *octree {
+	index := 0
+	if b.X >= o.X+o.Size/2 {
+		index |= 1
+	}
+	if b.Y >= o.Y+o.Size/2 {
+		index |= 2
+	}
+	if b.Z >= o.Z+o.Size/2 {
+		index |= 4
+	}
+	return &o.Children[index]
+}
+
+func (o *octree) summarize(bodies []BodyState) {
+	if o.Children == nil {
+		for _, i := range o.Bodies {
+			m := bodies[i].GetMass()
+			o.Mass += m
+			o.CX += m * bodies[i].X
+			o.CY += m * bodies[i].Y
+			o.CZ += m * bodies[i].Z
+		}
+	} else {
+		for c := range o.Children {
+			child := &o.Children[c]
+			child.summarize(bodies)
+			o.Mass += child.Mass
+			o.CX += child.Mass * child.CX
+			o.CY += child.Mass * child.CY
+			o.CZ += child.Mass * child.CZ
+		}
+	}
+	if o.Mass != 0 {
+		o.CX /= o.Mass
+		o.CY /= o.Mass
+		o.CZ /= o.Mass
+	}
+}
+
+func (o *octree) contains(b BodyState) bool {
+	return b.X >= o.X && b.X < o.X+o.Size &&
+		b.Y >= o.Y && b.Y < o.Y+o.Size &&
+		b.Z >= o.Z && b.Z < o.Z+o.Size
+}
+
+// getAcceleration on body i, treating every node seen under an angle smaller
+// than theta as a single mass at its centre of mass
+func (o *octree) getAcceleration(bodies []BodyState, i int, theta float64, settings Settings) Acceleration {
+	acceleration := Acceleration{}
+	if o.Mass == 0 {
+		return acceleration
+	}
+	b := bodies[i]
+	if o.Children == nil {
+		for _, j := range o.Bodies {
+			if j != i {
+				addAttraction(&acceleration, b, bodies[j].X, bodies[j].Y, bodies[j].Z, bodies[j].GetMass(), settings)
+			}
+		}
+		return acceleration
+	}
+	// The distance is discounted by how far the centre of mass is from the
+	// centre of the node, which bounds the error of lopsided nodes
+	half := o.Size / 2
+	distance := distance3D(o.CX-b.X, o.CY-b.Y, o.CZ-b.Z)
+	offset := distance3D(o.CX-(o.X+half), o.CY-(o.Y+half), o.CZ-(o.Z+half))
+	if !o.contains(b) && o.Size < theta*(distance-offset) {
+		addAttraction(&acceleration, b, o.CX, o.CY, o.CZ, o.Mass, settings)
+		return acceleration
+	}
+	for c := range o.Children {
+		childAcceleration := o.Children[c].getAcceleration(bodies, i, theta, settings)
+		acceleration.AX += childAcceleration.AX
+		acceleration.AY += childAcceleration.AY
+		acceleration.AZ += childAcceleration.AZ
+	}
+	return acceleration
+}
+
+func distance3D(dx, dy, dz float64) float64 {
+	return math.Sqrt(dx*dx + dy*dy + dz*dz)
+}
+
+func addAttraction(acceleration *Acceleration, b BodyState, x, y, z, mass float64, settings Settings) {
+	dx := x - b.X
+	dy := y - b.Y
+	dz := z - b.Z
+	distance2 := dx*dx + dy*dy + dz*dz + settings.Softening*settings.Softening
+	if distance2 == 0 {
+		return
+	}
+	factor := settings.GravitationalConstant * mass / (distance2 * math.Sqrt(distance2))
+	acceleration.AX += factor * dx
+	acceleration.AY += factor * dy
+	acceleration.AZ += factor * dz
+}
+
+// addBarnesHutGravity approximates the mutual attraction between bodies in
+// O(n log n), building a new tree on every evaluation
+func addBarnesHutGravity(bodies []BodyState, accelerations []Acceleration, settings Settings) {
+	if len(bodies) == 0 {
+		return
+	}
+	tree := newOctree(bodies)
+	parallelFor(len(bodies), settings.GetWorkers(), func(i int) {
+		acceleration := tree.getAcceleration(bodies, i, settings.Theta, settings)
+		accelerations[i].AX += acceleration.AX
+		accelerations[i].AY += acceleration.AY
+		accelerations[i].AZ += acceleration.AZ
+	})
+}
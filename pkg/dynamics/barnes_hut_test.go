@@ -0,0 +1,76 @@
+package dynamics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func nBodyAccelerations(bodies []BodyState, theta float64) []Acceleration {
+	settings := SETTINGS
+	settings.GravitationalConstant = 1
+	settings.Softening = 1
+	settings.Theta = theta
+	return State{Settings: settings}.GetAccelerations(bodies)
+}
+
+func TestBarnesHutMatchesDirectSum(t *testing.T) {
+
+	bodies, _ := randomBodies(2000, 1000)
+	for i := range bodies {
+		bodies[i].Mass = 1 + float64(i%7)
+	}
+
+	direct := nBodyAccelerations(bodies, 0)
+	approximated := nBodyAccelerations(bodies, 0.5)
+
+	// Errors are relative to the typical acceleration, since the net pull on
+	// bodies near the centre of the cloud almost cancels out
+	typical := 0.0
+	for i := range bodies {
+		typical += math.Hypot(direct[i].AX, direct[i].AY) / float64(len(bodies))
+	}
+
+	maxError := 0.0
+	totalError := 0.0
+	for i := range bodies {
+		relativeError := math.Hypot(approximated[i].AX-direct[i].AX, approximated[i].AY-direct[i].AY) / typical
+		maxError = math.Max(maxError, relativeError)
+		totalError += relativeError
+	}
+
+	assert.Less(t, maxError, 0.05)
+	assert.Less(t, totalError/float64(len(bodies)), 0.005)
+}
+
+func TestBarnesHutWithTinyThetaIsExact(t *testing.T) {
+
+	bodies, _ := randomBodies(200, 1000)
+	// Coincident bodies must not break the tree
+	bodies = append(bodies, bodies[0], bodies[0])
+
+	direct := nBodyAccelerations(bodies, 0)
+	approximated := nBodyAccelerations(bodies, 1e-9)
+
+	for i := range bodies {
+		assert.InDelta(t, direct[i].AX, approximated[i].AX, 1e-12)
+		assert.InDelta(t, direct[i].AY, approximated[i].AY, 1e-12)
+	}
+}
+
+func BenchmarkDirectSumGravity(b *testing.B) {
+	bodies, _ := randomBodies(5000, 1000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		nBodyAccelerations(bodies, 0)
+	}
+}
+
+func BenchmarkBarnesHutGravity(b *testing.B) {
+	bodies, _ := randomBodies(5000, 1000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		nBodyAccelerations(bodies, 0.5)
+	}
+}
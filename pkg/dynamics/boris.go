@@ -0,0 +1,54 @@
+package dynamics
+
+// BorisIntegrator is the second order Boris pusher, rotating the velocity
+// exactly in the field of a MagneticSystem, and leapfrog otherwise
+type BorisIntegrator struct{}
+
+func (BorisIntegrator) Step(bodies []BodyState, deltaTime float64, system System) []BodyState {
+	next := make([]BodyState, len(bodies))
+	for i := range bodies {
+		next[i] = bodies[i].Clone()
+		next[i].X += bodies[i].VX * deltaTime / 2
+		next[i].Y += bodies[i].VY * deltaTime / 2
+		next[i].Z += bodies[i].VZ * deltaTime / 2
+	}
+
+	magnetic, ok := system.(MagneticSystem)
+	if !ok {
+		accelerations := system.GetAccelerations(next)
+		for i := range next {
+			next[i].VX += accelerations[i].AX * deltaTime
+			next[i].VY += accelerations[i].AY * deltaTime
+			next[i].VZ += accelerations[i].AZ * deltaTime
+		}
+	} else {
+		accelerations := magnetic.GetNonMagneticAccelerations(next)
+		b := magnetic.GetMagneticField()
+		for i := range next {
+			n := &next[i]
+			n.VX += accelerations[i].AX * deltaTime / 2
+			n.VY += accelerations[i].AY * deltaTime / 2
+			n.VZ += accelerations[i].AZ * deltaTime / 2
+
+			// Rotation by the angle Charge * |B| * deltaTime / Mass
+			h := n.Charge / n.GetMass() * deltaTime / 2
+			tx, ty, tz := h*b.X, h*b.Y, h*b.Z
+			s := 2 / (1 + tx*tx + ty*ty + tz*tz)
+			cx, cy, cz := cross(n.VX, n.VY, n.VZ, tx, ty, tz)
+			px, py, pz := n.VX+cx, n.VY+cy, n.VZ+cz
+			cx, cy, cz = cross(px, py, pz, s*tx, s*ty, s*tz)
+			n.VX, n.VY, n.VZ = n.VX+cx, n.VY+cy, n.VZ+cz
+
+			n.VX += accelerations[i].AX * deltaTime / 2
+			n.VY += accelerations[i].AY * deltaTime / 2
+			n.VZ += accelerations[i].AZ * deltaTime / 2
+		}
+	}
+
+	for i := range next {
+		next[i].X += next[i].VX * deltaTime / 2
+		next[i].Y += next[i].VY * deltaTime / 2
+		next[i].Z += next[i].VZ * deltaTime / 2
+	}
+	return next
+}
@@ -0,0 +1,127 @@
+package dynamics
+
+import "math"
+
+// Boundary corrects every body after each integration step, returning false
+// when it must be removed. Boxes only limit Z when MinZ < MaxZ.
+type Boundary interface {
+	Apply(body BodyState, radius float64) (BodyState, bool)
+}
+
+// ReflectiveBoundary bounces bodies moving into the walls of a box, with
+// Restitution for bodies without their own. Like theirs it defaults to
+// BOUNCING_CONSERVATION, and NO_RESTITUTION stops the bodies. Friction is lost
+// along the wall on each hit.
+type ReflectiveBoundary struct {
+	MinX, MinY, MaxX, MaxY float64
+	Restitution            float64
+	Friction               float64
+	MinZ, MaxZ             float64
+}
+
+func (r ReflectiveBoundary) Apply(b BodyState, radius float64) (BodyState, bool) {
+
+	restitution := getRestitution(b.Restitution, getRestitution(r.Restitution, BOUNCING_CONSERVATION))
+	friction := 1 - r.Friction
+
+	if b.Y > r.MaxY-radius && b.VY > 0 {
+		b.Y = r.MaxY - radius
+		b.VX, b.VY, b.VZ = friction*b.VX, -restitution*b.VY, friction*b.VZ
+	}
+	if b.Y < r.MinY+radius && b.VY < 0 {
+		b.Y = r.MinY + radius
+		b.VX, b.VY, b.VZ = friction*b.VX, -restitution*b.VY, friction*b.VZ
+	}
+	if b.X > r.MaxX-radius && b.VX > 0 {
+		b.X = r.MaxX - radius
+		b.VX, b.VY, b.VZ = -restitution*b.VX, friction*b.VY, friction*b.VZ
+	}
+	if b.X < r.MinX+radius && b.VX < 0 {
+		b.X = r.MinX + radius
+		b.VX, b.VY, b.VZ = -restitution*b.VX, friction*b.VY, friction*b.VZ
+	}
+	if r.MinZ < r.MaxZ && b.Z > r.MaxZ-radius && b.VZ > 0 {
+		b.Z = r.MaxZ - radius
+		b.VX, b.VY, b.VZ = friction*b.VX, friction*b.VY, -restitution*b.VZ
+	}
+	if r.MinZ < r.MaxZ && b.Z < r.MinZ+radius && b.VZ < 0 {
+		b.Z = r.MinZ + radius
+		b.VX, b.VY, b.VZ = friction*b.VX, friction*b.VY, -restitution*b.VZ
+	}
+
+	return b, true
+}
+
+// PeriodicBoundary wraps bodies leaving one side of the box around to the
+// opposite side
+type PeriodicBoundary struct {
+	MinX, MinY, MaxX, MaxY float64
+	MinZ, MaxZ             float64
+}
+
+func (p PeriodicBoundary) Apply(b BodyState, radius float64) (BodyState, bool) {
+	b.X = wrap(b.X, p.MinX, p.MaxX)
+	b.Y = wrap(b.Y, p.MinY, p.MaxY)
+	b.Z = wrap(b.Z, p.MinZ, p.MaxZ)
+	return b, true
+}
+
+func wrap(value, min, max float64) float64 {
+	size := max - min
+	if size <= 0 {
+		return value
+	}
+	wrapped := math.Mod(value-min, size)
+	if wrapped < 0 {
+		wrapped += size
+	}
+	return min + wrapped
+}
+
+// AbsorbingBoundary removes bodies once they are completely outside the box
+type AbsorbingBoundary struct {
+	MinX, MinY, MaxX, MaxY float64
+	MinZ, MaxZ             float64
+}
+
+func (a AbsorbingBoundary) Apply(b BodyState, radius float64) (BodyState, bool) {
+	inside := b.X+radius >= a.MinX && b.X-radius <= a.MaxX &&
+		b.Y+radius >= a.MinY && b.Y-radius <= a.MaxY
+	if a.MinZ < a.MaxZ {
+		inside = inside && b.Z+radius >= a.MinZ && b.Z-radius <= a.MaxZ
+	}
+	return b, inside
+}
+
+// UnboundedBoundary lets bodies move freely through open space
+type UnboundedBoundary struct{}
+
+func (UnboundedBoundary) Apply(b BodyState, radius float64) (BodyState, bool) {
+	return b, true
+}
+
+// applyBoundary corrects the bodies with the boundary of the settings,
+// flagging the ones it removes and returning the hits
+func applyBoundary(bodies []BodyState, settings Settings) ([]bool, []BoundaryHit) {
+	boundary := settings.GetBoundary()
+	wraps := false
+	switch boundary.(type) {
+	case PeriodicBoundary, *PeriodicBoundary:
+		wraps = true
+	}
+	removed := make([]bool, len(bodies))
+	var hits []BoundaryHit
+	for i := range bodies {
+		before := bodies[i]
+		var keep bool
+		bodies[i], keep = boundary.Apply(bodies[i], bodies[i].GetRadius(settings))
+		removed[i] = !keep
+		if wraps {
+			continue
+		}
+		if hit, ok := getBoundaryHit(i, before, bodies[i], keep); ok {
+			hits = append(hits, hit)
+		}
+	}
+	return removed, hits
+}
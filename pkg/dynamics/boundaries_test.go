@@ -0,0 +1,134 @@
+package dynamics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReflectiveBoundary(t *testing.T) {
+
+	boundary := ReflectiveBoundary{MaxX: 100, MaxY: 100, Restitution: 0.5, Friction: 0.1}
+
+	b, keep := boundary.Apply(BodyState{X: 50, Y: 102, VX: 10, VY: 4}, 5)
+	assert.True(t, keep)
+	assert.Equal(t, BodyState{X: 50, Y: 95, VX: 9, VY: -2}, b)
+
+	b, _ = boundary.Apply(BodyState{X: 1, Y: 50, VX: -4, VY: 10}, 2)
+	assert.Equal(t, BodyState{X: 2, Y: 50, VX: 2, VY: 9}, b)
+
+	// The body restitution has precedence
+	b, _ = boundary.Apply(BodyState{X: 1, Y: 50, VX: -4, Restitution: 1}, 2)
+	assert.Equal(t, 4.0, b.VX)
+	b, _ = boundary.Apply(BodyState{X: 1, Y: 50, VX: -4, VY: 10, Restitution: NO_RESTITUTION}, 2)
+	assert.Equal(t, BodyState{X: 2, Y: 50, VX: 0, VY: 9, Restitution: NO_RESTITUTION}, b)
+
+	// Like that of the bodies, the restitution of the boundary defaults to
+	// BOUNCING_CONSERVATION, and NO_RESTITUTION stops the bodies without
+	// their own
+	boundary.Restitution = 0
+	b, _ = boundary.Apply(BodyState{X: 1, Y: 50, VX: -4}, 2)
+	assert.Equal(t, 4*BOUNCING_CONSERVATION, b.VX)
+	boundary.Restitution = NO_RESTITUTION
+	b, _ = boundary.Apply(BodyState{X: 1, Y: 50, VX: -4}, 2)
+	assert.Equal(t, 0.0, b.VX)
+	b, _ = boundary.Apply(BodyState{X: 1, Y: 50, VX: -4, Restitution: 1}, 2)
+	assert.Equal(t, 4.0, b.VX)
+
+	// Bodies inside the box are left untouched
+	b, _ = boundary.Apply(BodyState{X: 50, Y: 50, VX: -4, VY: 3}, 2)
+	assert.Equal(t, BodyState{X: 50, Y: 50, VX: -4, VY: 3}, b)
+}
+
+func TestReflectiveBoundaryMovingAway(t *testing.T) {
+
+	boundary := ReflectiveBoundary{MaxX: 100, MaxY: 100, MinZ: -10, MaxZ: 10, Restitution: 0.5, Friction: 0.1}
+
+	// Bodies starting within their radius of a wall and moving away from it
+	// are not bounced back into it
+	for _, body := range []BodyState{
+		{X: 50, Y: 98, VX: 10, VY: -4},
+		{X: 50, Y: 1, VX: 10, VY: 4},
+		{X: 99, Y: 50, VX: -4, VY: 10},
+		{X: 1, Y: 50, VX: 4, VY: 10},
+		{X: 50, Y: 50, Z: 9, VZ: -4},
+		{X: 50, Y: 50, Z: -9, VZ: 4},
+	} {
+		b, keep := boundary.Apply(body, 2)
+		assert.True(t, keep)
+		assert.Equal(t, body, b)
+	}
+
+	// A body in a corner only bounces off the wall it moves into
+	b, _ := boundary.Apply(BodyState{X: 1, Y: 1, VX: 4, VY: -4}, 2)
+	assert.Equal(t, BodyState{X: 1, Y: 2, VX: 3.6, VY: 2}, b)
+}
+
+func TestPeriodicBoundary(t *testing.T) {
+
+	boundary := PeriodicBoundary{MinX: -10, MaxX: 10, MaxY: 100}
+
+	b, keep := boundary.Apply(BodyState{X: 12, Y: -5, VX: 1, VY: -1}, 1)
+	assert.True(t, keep)
+	assert.Equal(t, BodyState{X: -8, Y: 95, VX: 1, VY: -1}, b)
+
+	b, _ = boundary.Apply(BodyState{X: -31, Y: 250}, 1)
+	assert.Equal(t, BodyState{X: 9, Y: 50}, b)
+}
+
+func TestAbsorbingBoundary(t *testing.T) {
+
+	settings := SETTINGS
+	settings.Boundary = AbsorbingBoundary{MaxX: 100, MaxY: 100}
+
+	s := State{
+		Settings: settings,
+		Bodies: []BodyState{
+			{X: 50, Y: 50, VX: 1},
+			{X: 105, Y: 50, VX: 1},
+			{X: 99, Y: 50, VX: 2, Radius: 2},
+		},
+		GravitySources: []GravitySource{},
+	}
+
+	s = UpdateState(s)
+
+	assert.Equal(t, []BodyState{
+		{X: 51, Y: 50, VX: 1},
+		{X: 101, Y: 50, VX: 2, Radius: 2},
+	}, s.Bodies)
+}
+
+func TestUnboundedBoundaryAndDrag(t *testing.T) {
+
+	settings := SETTINGS
+	settings.Boundary = UnboundedBoundary{}
+	settings.Integrator = EulerIntegrator{}
+	settings.Drag = 0.1
+
+	s := State{
+		Settings: settings,
+		Bodies: []BodyState{
+			{X: -50, Y: 2000, VX: -10},
+		},
+		GravitySources: []GravitySource{},
+	}
+
+	s = UpdateState(s)
+
+	assert.Equal(t, []BodyState{{X: -60, Y: 2000, VX: -9}}, s.Bodies)
+}
+
+func TestDefaultBoundaryIsUnbounded(t *testing.T) {
+
+	s := State{
+		Settings: Settings{DeltaTime: 1, DefaultRadius: 5},
+		Bodies: []BodyState{
+			{X: 998, Y: -500, VX: 10},
+		},
+	}
+
+	s = UpdateState(s)
+
+	assert.Equal(t, []BodyState{{X: 1008, Y: -500, VX: 10}}, s.Bodies)
+}
@@ -0,0 +1,210 @@
+package dynamics
+
+import (
+	"math"
+	"sort"
+)
+
+// BroadPhase finds the pairs of bodies that may be colliding, sorted with the
+// lower index first
+type BroadPhase interface {
+	GetCandidatePairs(bodies []BodyState, radii []float64) [][2]int
+}
+
+// BruteForceBroadPhase returns every pair of bodies. It is the broad-phase
+// used when Settings.BroadPhase is not set.
+type BruteForceBroadPhase struct{}
+
+func (BruteForceBroadPhase) GetCandidatePairs(bodies []BodyState, radii []float64) [][2]int {
+	pairs := [][2]int{}
+	for i := range bodies {
+		for j := i + 1; j < len(bodies); j++ {
+			pairs = append(pairs, [2]int{i, j})
+		}
+	}
+	return pairs
+}
+
+// SpatialHashBroadPhase buckets the bodies in a uniform grid. CellSize
+// defaults to the largest body diameter when unset.
+type SpatialHashBroadPhase struct {
+	CellSize float64
+}
+
+func (s SpatialHashBroadPhase) GetCandidatePairs(bodies []BodyState, radii []float64) [][2]int {
+	return NewSpatialHash(bodies, radii, s.CellSize).GetCandidatePairs()
+}
+
+// SweepAndPruneBroadPhase sorts the bodies along the X axis and only pairs the
+// ones whose extents overlap on every axis
+type SweepAndPruneBroadPhase struct{}
+
+func (SweepAndPruneBroadPhase) GetCandidatePairs(bodies []BodyState, radii []float64) [][2]int {
+
+	order := make([]int, len(bodies))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		return bodies[order[a]].X-radii[order[a]] < bodies[order[b]].X-radii[order[b]]
+	})
+
+	pairs := [][2]int{}
+	active := []int{}
+	for _, i := range order {
+		minX := bodies[i].X - radii[i]
+		stillActive := active[:0]
+		for _, j := range active {
+			if bodies[j].X+radii[j] >= minX {
+				stillActive = append(stillActive, j)
+			}
+		}
+		active = stillActive
+		for _, j := range active {
+			reach := radii[i] + radii[j]
+			if math.Abs(bodies[i].Y-bodies[j].Y) <= reach && math.Abs(bodies[i].Z-bodies[j].Z) <= reach {
+				pairs = append(pairs, sortedPair(i, j))
+			}
+		}
+		active = append(active, i)
+	}
+
+	sortPairs(pairs)
+	return pairs
+}
+
+// SpatialHash is a uniform grid of bodies answering proximity queries
+type SpatialHash struct {
+	bodies   []BodyState
+	radii    []float64
+	cellSize float64
+	cells    map[[3]int][]int
+	// Largest radius, so that queries look far enough around each cell
+	maxRadius float64
+}
+
+func NewSpatialHash(bodies []BodyState, radii []float64, cellSize float64) *SpatialHash {
+	h := SpatialHash{bodies, radii, cellSize, map[[3]int][]int{}, 0}
+	for i := range radii {
+		h.maxRadius = math.Max(h.maxRadius, radii[i])
+	}
+	if h.cellSize <= 0 {
+		h.cellSize = 2 * h.maxRadius
+	}
+	if h.cellSize <= 0 {
+		h.cellSize = 1
+	}
+	for i := range bodies {
+		// Bodies that diverged have no cell, and are never near anything
+		if !isFinitePoint(bodies[i].X, bodies[i].Y, bodies[i].Z) {
+			continue
+		}
+		cell := h.getCell(bodies[i].X, bodies[i].Y, bodies[i].Z)
+		h.cells[cell] = append(h.cells[cell], i)
+	}
+	return &h
+}
+
+func isFinitePoint(x, y, z float64) bool {
+	return !math.IsNaN(x+y+z) && !math.IsInf(x+y+z, 0)
+}
+
+func (h *SpatialHash) getCell(x, y, z float64) [3]int {
+	return [3]int{
+		int(math.Floor(x / h.cellSize)),
+		int(math.Floor(y / h.cellSize)),
+		int(math.Floor(z / h.cellSize)),
+	}
+}
+
+// forEachNear calls f for every body whose centre may be within distance of
+// the point
+func (h *SpatialHash) forEachNear(x, y, z, distance float64, f func(int)) {
+	if !isFinitePoint(x, y, z) || math.IsNaN(distance) {
+		return
+	}
+	// Large boxes have more cells than there are occupied ones
+	side := 2*distance/h.cellSize + 1
+	if side*side*side > float64(len(h.cells)) {
+		min := [3]float64{x - distance, y - distance, z - distance}
+		max := [3]float64{x + distance, y + distance, z + distance}
+		for cell, indices := range h.cells {
+			if h.cellOverlaps(cell, min, max) {
+				for _, i := range indices {
+					f(i)
+				}
+			}
+		}
+		return
+	}
+	min := h.getCell(x-distance, y-distance, z-distance)
+	max := h.getCell(x+distance, y+distance, z+distance)
+	for cx := min[0]; cx <= max[0]; cx++ {
+		for cy := min[1]; cy <= max[1]; cy++ {
+			for cz := min[2]; cz <= max[2]; cz++ {
+				for _, i := range h.cells[[3]int{cx, cy, cz}] {
+					f(i)
+				}
+			}
+		}
+	}
+}
+
+// cellOverlaps tells whether the cell intersects the box from min to max
+func (h *SpatialHash) cellOverlaps(cell [3]int, min, max [3]float64) bool {
+	for axis := range cell {
+		low := float64(cell[axis]) * h.cellSize
+		if low > max[axis] || low+h.cellSize < min[axis] {
+			return false
+		}
+	}
+	return true
+}
+
+func (h *SpatialHash) GetCandidatePairs() [][2]int {
+	pairs := [][2]int{}
+	for i, b := range h.bodies {
+		h.forEachNear(b.X, b.Y, b.Z, h.radii[i]+h.maxRadius, func(j int) {
+			if j <= i {
+				return
+			}
+			reach := h.radii[i] + h.radii[j]
+			if math.Abs(b.X-h.bodies[j].X) <= reach && math.Abs(b.Y-h.bodies[j].Y) <= reach &&
+				math.Abs(b.Z-h.bodies[j].Z) <= reach {
+				pairs = append(pairs, [2]int{i, j})
+			}
+		})
+	}
+	sortPairs(pairs)
+	return pairs
+}
+
+// GetNeighbours returns, in increasing order, the indices of the bodies whose
+// centre is within distance of the point
+func (h *SpatialHash) GetNeighbours(x, y, z, distance float64) []int {
+	neighbours := []int{}
+	h.forEachNear(x, y, z, distance, func(i int) {
+		dx, dy, dz := h.bodies[i].X-x, h.bodies[i].Y-y, h.bodies[i].Z-z
+		if math.Sqrt(dx*dx+dy*dy+dz*dz) <= distance {
+			neighbours = append(neighbours, i)
+		}
+	})
+	sort.Ints(neighbours)
+	return neighbours
+}
+
+func sortedPair(i, j int) [2]int {
+	if i > j {
+		return [2]int{j, i}
+	}
+	return [2]int{i, j}
+}
+
+func sortPairs(pairs [][2]int) {
+	sort.Slice(pairs, func(a, b int) bool {
+		if pairs[a][0] != pairs[b][0] {
+			return pairs[a][0] < pairs[b][0]
+		}
+		return pairs[a][1] < pairs[b][1]
+	})
+}
@@ -0,0 +1,130 @@
+package dynamics
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func randomBodies(n int, size float64) ([]BodyState, []float64) {
+	random := rand.New(rand.NewSource(42))
+	bodies := make([]BodyState, n)
+	radii := make([]float64, n)
+	for i := range bodies {
+		bodies[i] = BodyState{X: random.Float64() * size, Y: random.Float64() * size}
+		radii[i] = 1 + random.Float64()*4
+	}
+	return bodies, radii
+}
+
+func overlappingPairs(bodies []BodyState, radii []float64, pairs [][2]int) [][2]int {
+	overlapping := [][2]int{}
+	for _, p := range pairs {
+		a := bodies[p[0]]
+		b := bodies[p[1]]
+		if math.Hypot(a.X-b.X, a.Y-b.Y) < radii[p[0]]+radii[p[1]] {
+			overlapping = append(overlapping, p)
+		}
+	}
+	return overlapping
+}
+
+func TestBroadPhasesFindAllCollisions(t *testing.T) {
+
+	bodies, radii := randomBodies(1000, 500)
+	expected := overlappingPairs(bodies, radii, BruteForceBroadPhase{}.GetCandidatePairs(bodies, radii))
+	assert.NotEmpty(t, expected)
+
+	broadPhases := []BroadPhase{
+		SpatialHashBroadPhase{},
+		SpatialHashBroadPhase{CellSize: 3},
+		SpatialHashBroadPhase{CellSize: 50},
+		SweepAndPruneBroadPhase{},
+	}
+
+	for _, broadPhase := range broadPhases {
+		candidates := broadPhase.GetCandidatePairs(bodies, radii)
+		assert.Less(t, len(candidates), len(bodies)*len(bodies)/20)
+		assert.Equal(t, expected, overlappingPairs(bodies, radii, candidates), "%#v", broadPhase)
+	}
+}
+
+func TestSpatialHashNeighbours(t *testing.T) {
+
+	bodies, radii := randomBodies(1000, 500)
+	hash := NewSpatialHash(bodies, radii, 0)
+
+	expected := []int{}
+	for i, b := range bodies {
+		if math.Hypot(b.X-250, b.Y-100) <= 30 {
+			expected = append(expected, i)
+		}
+	}
+
+	assert.NotEmpty(t, expected)
+	assert.Equal(t, expected, hash.GetNeighbours(250, 100, 0, 30))
+	assert.Empty(t, hash.GetNeighbours(-100, -100, 0, 30))
+}
+
+func TestSpatialHashLargeQueries(t *testing.T) {
+
+	bodies, radii := randomBodies(100, 500)
+	// Tiny cells make the box of the query span billions of cells
+	hash := NewSpatialHash(bodies, radii, 1e-3)
+
+	assert.Len(t, hash.GetNeighbours(250, 250, 0, 1e4), 100)
+	assert.Len(t, hash.GetNeighbours(0, 0, 0, math.Inf(1)), 100)
+	expected := []int{}
+	for i, b := range bodies {
+		if math.Hypot(b.X-250, b.Y-100) <= 30 {
+			expected = append(expected, i)
+		}
+	}
+	assert.Equal(t, expected, hash.GetNeighbours(250, 100, 0, 30))
+}
+
+func TestSpatialHashNonFinite(t *testing.T) {
+
+	bodies := []BodyState{{X: 0}, {X: math.NaN()}, {X: 1}, {Y: math.Inf(1)}}
+	radii := []float64{1, 1, 1, 1}
+	hash := NewSpatialHash(bodies, radii, 0)
+
+	assert.Equal(t, []int{0, 2}, hash.GetNeighbours(0, 0, 0, 5))
+	assert.Empty(t, hash.GetNeighbours(math.NaN(), 0, 0, 5))
+	assert.Empty(t, hash.GetNeighbours(0, math.Inf(-1), 0, 5))
+	assert.Empty(t, hash.GetNeighbours(0, 0, 0, math.NaN()))
+	assert.Equal(t, [][2]int{{0, 2}}, SpatialHashBroadPhase{}.GetCandidatePairs(bodies, radii))
+}
+
+func TestCollisionsWithBroadPhase(t *testing.T) {
+
+	s := collisionState(ElasticCollisions)
+	s.Settings.BroadPhase = SweepAndPruneBroadPhase{}
+
+	s = UpdateState(s)
+
+	assert.Len(t, s.Collisions, 1)
+	assert.InDelta(t, -2.0, s.Bodies[0].VX, 1e-12)
+}
+
+func benchmarkBroadPhase(b *testing.B, broadPhase BroadPhase) {
+	bodies, radii := randomBodies(5000, 2000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		overlappingPairs(bodies, radii, broadPhase.GetCandidatePairs(bodies, radii))
+	}
+}
+
+func BenchmarkBruteForceBroadPhase(b *testing.B) {
+	benchmarkBroadPhase(b, BruteForceBroadPhase{})
+}
+
+func BenchmarkSpatialHashBroadPhase(b *testing.B) {
+	benchmarkBroadPhase(b, SpatialHashBroadPhase{})
+}
+
+func BenchmarkSweepAndPruneBroadPhase(b *testing.B) {
+	benchmarkBroadPhase(b, SweepAndPruneBroadPhase{})
+}
@@ -0,0 +1,236 @@
+package dynamics
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/rpagliuca/go-physics/pkg/checkpoint"
+)
+
+const CHECKPOINT_KIND = "dynamics.State"
+
+// CHECKPOINT_LAYOUT is the version of the layout of the scene structures in
+// checkpoints, which are written field by field in declaration order. It must
+// be increased whenever those structures change, and checkpoints of any other
+// layout are rejected.
+const CHECKPOINT_LAYOUT = 4
+
+// MAX_CHECKPOINT_LENGTH bounds the lists read from a checkpoint
+const MAX_CHECKPOINT_LENGTH = 1 << 24
+
+// Lists read from a checkpoint grow as their elements are read, so that a
+// corrupted length does not allocate more than this many elements up front
+const checkpointPreallocation = 1024
+
+// WriteCheckpoint saves the full state, including the integrator internals,
+// so that ReadCheckpoint resumes the simulation bit by bit
+func WriteCheckpoint(w io.Writer, state State) error {
+	file, err := newSceneFile(state)
+	if err != nil {
+		return err
+	}
+
+	writer := checkpoint.NewWriter(w, CHECKPOINT_KIND)
+	writer.WriteInt(CHECKPOINT_LAYOUT)
+	writer.WriteFloat64(state.Time)
+	writer.WriteInt(state.Step)
+	writeCheckpointValue(writer, reflect.ValueOf(file))
+	writeCheckpointValue(writer, reflect.ValueOf(state.Collisions))
+	for _, g := range state.GravitySources {
+		settings := getGravitySourceSettings(g)
+		writer.WriteFloat64(settings.GravityAcceleration)
+		writer.WriteFloat64(settings.GravitationalConstant)
+	}
+
+	integrator, adaptive := state.Settings.Integrator.(*DormandPrinceIntegrator)
+	writer.WriteBool(adaptive)
+	if adaptive {
+		writer.WriteFloat64(integrator.nextStep)
+		writer.WriteInt(integrator.SubSteps)
+		writer.WriteInt(integrator.RejectedSteps)
+	}
+	return writer.Flush()
+}
+
+func ReadCheckpoint(r io.Reader) (State, error) {
+	reader, err := checkpoint.NewReader(r, CHECKPOINT_KIND)
+	if err != nil {
+		return State{}, err
+	}
+
+	layout := reader.ReadInt()
+	if reader.Err() != nil {
+		return State{}, reader.Err()
+	}
+	if layout != CHECKPOINT_LAYOUT {
+		return State{}, fmt.Errorf("checkpoint layout %d, expected %d", layout, CHECKPOINT_LAYOUT)
+	}
+	time := reader.ReadFloat64()
+	step := reader.ReadInt()
+	var file sceneFile
+	if err := readCheckpointValue(reader, reflect.ValueOf(&file).Elem()); err != nil {
+		return State{}, err
+	}
+	var collisions []Collision
+	if err := readCheckpointValue(reader, reflect.ValueOf(&collisions).Elem()); err != nil {
+		return State{}, err
+	}
+	gravity := make([][2]float64, len(file.GravitySources))
+	for i := range gravity {
+		gravity[i] = [2]float64{reader.ReadFloat64(), reader.ReadFloat64()}
+	}
+	adaptive := reader.ReadBool()
+	var nextStep float64
+	var subSteps, rejectedSteps int
+	if adaptive {
+		nextStep = reader.ReadFloat64()
+		subSteps = reader.ReadInt()
+		rejectedSteps = reader.ReadInt()
+	}
+	if reader.Err() != nil {
+		return State{}, reader.Err()
+	}
+
+	state, err := file.getState(nil)
+	if err != nil {
+		return State{}, err
+	}
+	state.Time = time
+	state.Step = step
+	state.Collisions = collisions
+	for i, g := range state.GravitySources {
+		settings := getGravitySourceSettings(g)
+		settings.GravityAcceleration = gravity[i][0]
+		settings.GravitationalConstant = gravity[i][1]
+	}
+	if integrator, ok := state.Settings.Integrator.(*DormandPrinceIntegrator); ok && adaptive {
+		integrator.nextStep = nextStep
+		integrator.SubSteps = subSteps
+		integrator.RejectedSteps = rejectedSteps
+	}
+	return state, nil
+}
+
+// writeCheckpointValue writes the fields of the scene structures in order,
+// each polymorphic element preceded by its type
+func writeCheckpointValue(w *checkpoint.Writer, v reflect.Value) {
+
+	if v.Type() == sceneElementType {
+		value := reflect.Indirect(reflect.ValueOf(v.Interface().(sceneElement).value))
+		w.WriteString(value.FieldByName("Type").String())
+		writeCheckpointValue(w, value)
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			writeCheckpointValue(w, v.Field(i))
+		}
+	case reflect.Slice:
+		w.WriteInt(v.Len())
+		for i := 0; i < v.Len(); i++ {
+			writeCheckpointValue(w, v.Index(i))
+		}
+	case reflect.Ptr:
+		w.WriteBool(!v.IsNil())
+		if !v.IsNil() {
+			writeCheckpointValue(w, v.Elem())
+		}
+	case reflect.Float64:
+		w.WriteFloat64(v.Float())
+	case reflect.Int:
+		w.WriteInt(int(v.Int()))
+	case reflect.String:
+		w.WriteString(v.String())
+	case reflect.Bool:
+		w.WriteBool(v.Bool())
+	default:
+		panic(fmt.Sprintf("cannot write %s to a checkpoint", v.Type()))
+	}
+}
+
+func readCheckpointValue(r *checkpoint.Reader, v reflect.Value) error {
+
+	if v.Type() == sceneElementType {
+		name := r.ReadString()
+		if r.Err() != nil {
+			return r.Err()
+		}
+		factory, ok := getSceneFactories()[name]
+		if !ok {
+			return fmt.Errorf("unknown element type %q in checkpoint", name)
+		}
+		value := factory()
+		if err := readCheckpointValue(r, reflect.ValueOf(value).Elem()); err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(sceneElement{value: value}))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if err := readCheckpointValue(r, v.Field(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		length := r.ReadLength(MAX_CHECKPOINT_LENGTH)
+		if length == 0 {
+			break
+		}
+		capacity := length
+		if capacity > checkpointPreallocation {
+			capacity = checkpointPreallocation
+		}
+		slice := reflect.MakeSlice(v.Type(), 0, capacity)
+		for i := 0; i < length && r.Err() == nil; i++ {
+			element := reflect.New(v.Type().Elem()).Elem()
+			if err := readCheckpointValue(r, element); err != nil {
+				return err
+			}
+			slice = reflect.Append(slice, element)
+		}
+		v.Set(slice)
+	case reflect.Ptr:
+		if r.ReadBool() {
+			v.Set(reflect.New(v.Type().Elem()))
+			return readCheckpointValue(r, v.Elem())
+		}
+	case reflect.Float64:
+		v.SetFloat(r.ReadFloat64())
+	case reflect.Int:
+		v.SetInt(int64(r.ReadInt()))
+	case reflect.String:
+		v.SetString(r.ReadString())
+	case reflect.Bool:
+		v.SetBool(r.ReadBool())
+	}
+	return r.Err()
+}
+
+// getGravitySourceSettings returns the settings of a gravity source saved by
+// SaveScene, all of which keep them in their Settings field. Scenes give every
+// source the settings of the state, but checkpoints restore the fields the
+// sources read from their own.
+func getGravitySourceSettings(g GravitySource) *Settings {
+	return reflect.ValueOf(g).Elem().FieldByName("Settings").Addr().Interface().(*Settings)
+}
+
+// getSceneFactories merges the factories of all the polymorphic elements,
+// whose types have distinct names
+func getSceneFactories() map[string]func() interface{} {
+	factories := map[string]func() interface{}{}
+	for _, m := range []map[string]func() interface{}{
+		sceneIntegrators, sceneBoundaries, sceneBroadPhases,
+		sceneGravitySources, sceneForces, sceneConstraints, sceneElectricFields,
+	} {
+		for name, factory := range m {
+			factories[name] = factory
+		}
+	}
+	return factories
+}
@@ -0,0 +1,181 @@
+package dynamics
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/rpagliuca/go-physics/pkg/checkpoint"
+	"github.com/stretchr/testify/assert"
+)
+
+func checkpointState() State {
+	s := sceneState()
+	s.Settings.Boundary = UnboundedBoundary{}
+	s.Settings.Integrator = &DormandPrinceIntegrator{}
+	s.Settings.CollisionResponse = ElasticCollisions
+	s.Constraints = s.Constraints[1:]
+	s.Bodies = append(s.Bodies, BodyState{X: 30, Y: 10, VX: -1, Radius: 2})
+	for i := range s.GravitySources {
+		s.GravitySources[i] = s.GravitySources[i].Clone()
+	}
+	return s
+}
+
+func TestCheckpointResumesExactly(t *testing.T) {
+
+	uninterrupted := checkpointState()
+	for i := 0; i < 200; i++ {
+		uninterrupted = UpdateState(uninterrupted)
+	}
+
+	s := checkpointState()
+	for i := 0; i < 100; i++ {
+		s = UpdateState(s)
+	}
+	var buffer bytes.Buffer
+	assert.NoError(t, WriteCheckpoint(&buffer, s))
+	restored, err := ReadCheckpoint(&buffer)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, restored.Step)
+	assert.Equal(t, s.Time, restored.Time)
+	assert.Equal(t, s.Bodies, restored.Bodies)
+
+	for i := 0; i < 100; i++ {
+		restored = UpdateState(restored)
+	}
+
+	assert.Equal(t, uninterrupted.Bodies, restored.Bodies)
+	assert.Equal(t, uninterrupted.Time, restored.Time)
+	assert.Equal(t, 200, restored.Step)
+}
+
+func TestCheckpointGravitySourceSettings(t *testing.T) {
+
+	s := sceneState()
+	point := s.GravitySources[1].(*PointGravitySource)
+	point.Settings.GravitationalConstant = 3
+	ball := s.GravitySources[7].(*BallGravitySource)
+	ball.Settings.GravityAcceleration = 4
+
+	var buffer bytes.Buffer
+	assert.NoError(t, WriteCheckpoint(&buffer, s))
+	restored, err := ReadCheckpoint(&buffer)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3.0, restored.GravitySources[1].(*PointGravitySource).Settings.GravitationalConstant)
+	assert.Equal(t, 4.0, restored.GravitySources[7].(*BallGravitySource).Settings.GravityAcceleration)
+	assert.Equal(t, s.Settings.GravityAcceleration, restored.GravitySources[1].(*PointGravitySource).Settings.GravityAcceleration)
+	for _, b := range s.Bodies {
+		for i, g := range s.GravitySources {
+			assert.Equal(t, g.GetAcceleration(b), restored.GravitySources[i].GetAcceleration(b))
+		}
+	}
+}
+
+func TestCheckpointRoundTrip(t *testing.T) {
+
+	s := sceneState()
+	s.Time = 12.5
+	s.Step = 125
+	s.Collisions = []Collision{{First: 0, Second: 1, X: 1, NormalY: -1, Speed: 2}}
+
+	var buffer bytes.Buffer
+	assert.NoError(t, WriteCheckpoint(&buffer, s))
+	restored, err := ReadCheckpoint(&buffer)
+
+	assert.NoError(t, err)
+	assert.Equal(t, s, restored)
+}
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden checkpoint of the current layout")
+
+// goldenCheckpoint is the checkpoint of the current layout in testdata
+func goldenCheckpoint() string {
+	return filepath.Join("testdata", fmt.Sprintf("state-%d.checkpoint", CHECKPOINT_LAYOUT))
+}
+
+func TestCheckpointGolden(t *testing.T) {
+
+	s := sceneState()
+	s.Time = 12.5
+	s.Step = 125
+	s.Collisions = []Collision{{First: 0, Second: 1, X: 1, NormalY: -1, Speed: 2}}
+	var buffer bytes.Buffer
+	assert.NoError(t, WriteCheckpoint(&buffer, s))
+
+	if *updateGolden {
+		assert.NoError(t, ioutil.WriteFile(goldenCheckpoint(), buffer.Bytes(), 0644))
+	}
+	golden, err := ioutil.ReadFile(goldenCheckpoint())
+	assert.NoError(t, err, "run the test with -update to write the golden checkpoint")
+	// A change of the bytes means that the layout changed: increase
+	// CHECKPOINT_LAYOUT and write the new golden checkpoint with -update
+	assert.Equal(t, golden, buffer.Bytes())
+
+	restored, err := ReadCheckpoint(bytes.NewReader(golden))
+	assert.NoError(t, err)
+	assert.Equal(t, s, restored)
+}
+
+func TestCheckpointOldLayouts(t *testing.T) {
+
+	files, err := filepath.Glob(filepath.Join("testdata", "state-*.checkpoint"))
+	assert.NoError(t, err)
+	for _, file := range files {
+		if file == goldenCheckpoint() {
+			continue
+		}
+		var layout int
+		fmt.Sscanf(filepath.Base(file), "state-%d.checkpoint", &layout)
+		data, err := ioutil.ReadFile(file)
+		assert.NoError(t, err)
+		_, err = ReadCheckpoint(bytes.NewReader(data))
+		assert.EqualError(t, err, fmt.Sprintf("checkpoint layout %d, expected %d", layout, CHECKPOINT_LAYOUT))
+	}
+}
+
+func TestCheckpointErrors(t *testing.T) {
+
+	_, err := ReadCheckpoint(bytes.NewBufferString("{}"))
+	assert.EqualError(t, err, "not a checkpoint")
+
+	var buffer bytes.Buffer
+	assert.NoError(t, WriteCheckpoint(&buffer, sceneState()))
+	truncated := bytes.NewBuffer(buffer.Bytes()[:buffer.Len()/2])
+	_, err = ReadCheckpoint(truncated)
+	assert.Error(t, err)
+}
+
+// bodyCountCheckpoint returns a checkpoint which claims to hold the number of
+// bodies, but ends right after the count
+func bodyCountCheckpoint(bodies int) *bytes.Buffer {
+	var buffer bytes.Buffer
+	writer := checkpoint.NewWriter(&buffer, CHECKPOINT_KIND)
+	writer.WriteInt(CHECKPOINT_LAYOUT)
+	writer.WriteFloat64(0)
+	writer.WriteInt(0)
+	writeCheckpointValue(writer, reflect.ValueOf(sceneSettings{}))
+	writer.WriteInt(bodies)
+	writer.Flush()
+	return &buffer
+}
+
+func TestCheckpointCorruptedLength(t *testing.T) {
+
+	_, err := ReadCheckpoint(bodyCountCheckpoint(MAX_CHECKPOINT_LENGTH + 1))
+	assert.EqualError(t, err, "invalid length 16777217")
+
+	// A truncated list does not allocate the length it claims
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, err = ReadCheckpoint(bodyCountCheckpoint(MAX_CHECKPOINT_LENGTH))
+	runtime.ReadMemStats(&after)
+	assert.Error(t, err)
+	assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(1<<20))
+}
@@ -0,0 +1,150 @@
+package dynamics
+
+import "math"
+
+type CollisionResponse int
+
+const (
+	// Bodies pass through each other
+	NoCollisions CollisionResponse = iota
+	ElasticCollisions
+	// The restitution coefficient is the mean of the restitution of the bodies
+	InelasticCollisions
+	// The bodies merge into a single one, conserving mass and momentum
+	MergingCollisions
+)
+
+// Collision resolved during the last UpdateState, indexing the bodies left by
+// the boundary. When merging, Second is absorbed into First.
+type Collision struct {
+	First, Second int
+	// Contact point and normal, pointing from First to Second
+	X, Y, Z                   float64
+	NormalX, NormalY, NormalZ float64
+	// Speed at which the bodies were approaching along the normal
+	Speed float64
+}
+
+func getCollisions(bodies []BodyState, settings Settings) []Collision {
+	radii := make([]float64, len(bodies))
+	for i := range bodies {
+		radii[i] = bodies[i].GetRadius(settings)
+	}
+	collisions := []Collision{}
+	for _, pair := range settings.GetBroadPhase().GetCandidatePairs(bodies, radii) {
+		collision, ok := getCollision(bodies, pair[0], pair[1], settings)
+		if ok {
+			collisions = append(collisions, collision)
+		}
+	}
+	return collisions
+}
+
+func getCollision(bodies []BodyState, i, j int, settings Settings) (Collision, bool) {
+	a := bodies[i]
+	b := bodies[j]
+	dx := b.X - a.X
+	dy := b.Y - a.Y
+	dz := b.Z - a.Z
+	distance := math.Sqrt(dx*dx + dy*dy + dz*dz)
+	ra := a.GetRadius(settings)
+	if distance >= ra+b.GetRadius(settings) {
+		return Collision{}, false
+	}
+	nx, ny, nz := 1.0, 0.0, 0.0
+	if distance > 0 {
+		nx, ny, nz = dx/distance, dy/distance, dz/distance
+	}
+	speed := (a.VX-b.VX)*nx + (a.VY-b.VY)*ny + (a.VZ-b.VZ)*nz
+	return Collision{
+		i, j,
+		a.X + nx*ra, a.Y + ny*ra, a.Z + nz*ra,
+		nx, ny, nz,
+		speed,
+	}, true
+}
+
+// resolveCollisions returns the collisions found, flagging merged bodies
+func resolveCollisions(bodies []BodyState, settings Settings) ([]Collision, []bool) {
+
+	removed := make([]bool, len(bodies))
+	if settings.CollisionResponse == NoCollisions {
+		return nil, removed
+	}
+
+	collisions := getCollisions(bodies, settings)
+	resolved := collisions[:0]
+
+	for _, c := range collisions {
+		if removed[c.First] || removed[c.Second] {
+			continue
+		}
+		a := &bodies[c.First]
+		b := &bodies[c.Second]
+		switch settings.CollisionResponse {
+		case MergingCollisions:
+			*a = merge(*a, *b, settings)
+			removed[c.Second] = true
+		case ElasticCollisions:
+			bounce(a, b, c, 1, settings)
+		case InelasticCollisions:
+			bounce(a, b, c, (a.GetRestitution()+b.GetRestitution())/2, settings)
+		}
+		resolved = append(resolved, c)
+	}
+
+	return resolved, removed
+}
+
+// bounce separates two overlapping bodies and exchanges the impulse along the
+// collision normal
+func bounce(a, b *BodyState, c Collision, restitution float64, settings Settings) {
+
+	inverseA := 1 / a.GetMass()
+	inverseB := 1 / b.GetMass()
+
+	dx, dy, dz := b.X-a.X, b.Y-a.Y, b.Z-a.Z
+	overlap := a.GetRadius(settings) + b.GetRadius(settings) - math.Sqrt(dx*dx+dy*dy+dz*dz)
+	correction := overlap / (inverseA + inverseB)
+	a.X -= c.NormalX * correction * inverseA
+	a.Y -= c.NormalY * correction * inverseA
+	a.Z -= c.NormalZ * correction * inverseA
+	b.X += c.NormalX * correction * inverseB
+	b.Y += c.NormalY * correction * inverseB
+	b.Z += c.NormalZ * correction * inverseB
+
+	// Already moving apart
+	if c.Speed <= 0 {
+		return
+	}
+
+	impulse := (1 + restitution) * c.Speed / (inverseA + inverseB)
+	a.VX -= impulse * inverseA * c.NormalX
+	a.VY -= impulse * inverseA * c.NormalY
+	a.VZ -= impulse * inverseA * c.NormalZ
+	b.VX += impulse * inverseB * c.NormalX
+	b.VY += impulse * inverseB * c.NormalY
+	b.VZ += impulse * inverseB * c.NormalZ
+}
+
+// merge returns a single body with the mass, momentum, charge and volume
+// of both bodies, placed at their centre of mass
+func merge(a, b BodyState, settings Settings) BodyState {
+	ma := a.GetMass()
+	mb := b.GetMass()
+	mass := ma + mb
+	ra := a.GetRadius(settings)
+	rb := b.GetRadius(settings)
+
+	merged := a.Clone()
+	merged.X = (ma*a.X + mb*b.X) / mass
+	merged.Y = (ma*a.Y + mb*b.Y) / mass
+	merged.Z = (ma*a.Z + mb*b.Z) / mass
+	merged.VX = (ma*a.VX + mb*b.VX) / mass
+	merged.VY = (ma*a.VY + mb*b.VY) / mass
+	merged.VZ = (ma*a.VZ + mb*b.VZ) / mass
+	merged.Mass = mass
+	merged.Radius = math.Cbrt(ra*ra*ra + rb*rb*rb)
+	merged.Charge = a.Charge + b.Charge
+	return merged
+}
@@ -0,0 +1,94 @@
+package dynamics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func collisionState(response CollisionResponse) State {
+	settings := SETTINGS
+	settings.Boundary = UnboundedBoundary{}
+	settings.CollisionResponse = response
+	settings.DeltaTime = 0.1
+	return State{
+		Settings: settings,
+		Bodies: []BodyState{
+			{X: 0, Y: 0, VX: 2, Mass: 1, Radius: 1, Restitution: 0.5},
+			{X: 2.2, Y: 0, VX: -1, Mass: 2, Radius: 1, Restitution: 0.5},
+			{X: 100, Y: 100},
+		},
+	}
+}
+
+func TestNoCollisions(t *testing.T) {
+
+	s := UpdateState(collisionState(NoCollisions))
+
+	assert.Empty(t, s.Collisions)
+	assert.Equal(t, 2.0, s.Bodies[0].VX)
+	assert.Equal(t, -1.0, s.Bodies[1].VX)
+}
+
+func TestElasticCollision(t *testing.T) {
+
+	s := UpdateState(collisionState(ElasticCollisions))
+
+	assert.Len(t, s.Collisions, 1)
+	c := s.Collisions[0]
+	assert.Equal(t, []int{0, 1}, []int{c.First, c.Second})
+	assert.InDelta(t, 1.2, c.X, 1e-12)
+	assert.Equal(t, []float64{0, 0, 1, 0, 0}, []float64{c.Y, c.Z, c.NormalX, c.NormalY, c.NormalZ})
+	assert.InDelta(t, 3.0, c.Speed, 1e-12)
+	assert.InDelta(t, -2.0, s.Bodies[0].VX, 1e-12)
+	assert.InDelta(t, 1.0, s.Bodies[1].VX, 1e-12)
+	// The bodies no longer overlap
+	assert.InDelta(t, 2.0, s.Bodies[1].X-s.Bodies[0].X, 1e-12)
+
+	px, _, _ := s.LinearMomentum()
+	assert.InDelta(t, 0.0, px, 1e-12)
+	assert.InDelta(t, collisionState(ElasticCollisions).KineticEnergy(), s.KineticEnergy(), 1e-12)
+}
+
+func TestInelasticCollision(t *testing.T) {
+
+	s := UpdateState(collisionState(InelasticCollisions))
+
+	assert.Len(t, s.Collisions, 1)
+	// Relative velocity is reversed and halved
+	assert.InDelta(t, 1.5, s.Bodies[1].VX-s.Bodies[0].VX, 1e-12)
+	px, _, _ := s.LinearMomentum()
+	assert.InDelta(t, 0.0, px, 1e-12)
+}
+
+func TestPerfectlyInelasticCollision(t *testing.T) {
+
+	s := collisionState(InelasticCollisions)
+	s.Bodies[0].Restitution, s.Bodies[1].Restitution = NO_RESTITUTION, NO_RESTITUTION
+
+	s = UpdateState(s)
+
+	assert.Len(t, s.Collisions, 1)
+	// The bodies move together
+	assert.InDelta(t, 0.0, s.Bodies[1].VX-s.Bodies[0].VX, 1e-12)
+	assert.InDelta(t, 0.0, s.Bodies[0].VX, 1e-12)
+}
+
+func TestMergingCollision(t *testing.T) {
+
+	s0 := collisionState(MergingCollisions)
+	s0.Bodies[0].Charge = 1
+	s0.Bodies[1].Charge = -3
+
+	s := UpdateState(s0)
+
+	assert.Len(t, s.Collisions, 1)
+	assert.Len(t, s.Bodies, 2)
+	assert.Equal(t, 3.0, s.Bodies[0].Mass)
+	assert.Equal(t, -2.0, s.Bodies[0].Charge)
+	assert.InDelta(t, 0.0, s.Bodies[0].VX, 1e-12)
+	assert.InDelta(t, (0.2+2*2.1)/3.0, s.Bodies[0].X, 1e-12)
+	assert.InDelta(t, math.Cbrt(2), s.Bodies[0].Radius, 1e-12)
+	assert.Equal(t, 100.0, s.Bodies[1].X)
+}
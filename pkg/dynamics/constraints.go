@@ -0,0 +1,150 @@
+package dynamics
+
+import "github.com/rpagliuca/go-physics/pkg/algebra"
+
+const DEFAULT_CONSTRAINT_ITERATIONS = 10
+
+// Constraint restricts the positions of the bodies at its indices, and is
+// solved after each integration step
+type Constraint interface {
+	// Project moves the bodies towards satisfying the constraint, splitting
+	// the correction according to the inverse masses
+	Project(bodies []BodyState, inverseMasses []float64)
+	// Renumber updates the body indices after some bodies were removed, as in
+	// ForceElement
+	Renumber(indices []int) (Constraint, bool)
+	// GetPinnedBody returns the body held in place by the constraint, if any
+	GetPinnedBody() (int, bool)
+	Clone() Constraint
+}
+
+// DistanceConstraint keeps two bodies at a fixed distance, like a rigid rod
+type DistanceConstraint struct {
+	First, Second int
+	Length        float64
+}
+
+func (c DistanceConstraint) Project(bodies []BodyState, inverseMasses []float64) {
+	projectDistance(bodies, inverseMasses, c.First, c.Second, c.Length, false)
+}
+
+func (c DistanceConstraint) Renumber(indices []int) (Constraint, bool) {
+	c.First, c.Second = indices[c.First], indices[c.Second]
+	return c, c.First >= 0 && c.Second >= 0
+}
+
+func (c DistanceConstraint) GetPinnedBody() (int, bool) {
+	return 0, false
+}
+
+func (c DistanceConstraint) Clone() Constraint {
+	return c
+}
+
+// MaxDistanceConstraint keeps two bodies at most at the given distance, like a
+// rope
+type MaxDistanceConstraint struct {
+	First, Second int
+	Length        float64
+}
+
+func (c MaxDistanceConstraint) Project(bodies []BodyState, inverseMasses []float64) {
+	projectDistance(bodies, inverseMasses, c.First, c.Second, c.Length, true)
+}
+
+func (c MaxDistanceConstraint) Renumber(indices []int) (Constraint, bool) {
+	c.First, c.Second = indices[c.First], indices[c.Second]
+	return c, c.First >= 0 && c.Second >= 0
+}
+
+func (c MaxDistanceConstraint) GetPinnedBody() (int, bool) {
+	return 0, false
+}
+
+func (c MaxDistanceConstraint) Clone() Constraint {
+	return c
+}
+
+// FixedPointConstraint pins a body to a point. Pinned bodies are not moved by
+// the other constraints, so they can be used as anchors.
+type FixedPointConstraint struct {
+	Body  int
+	Point algebra.Point3D
+}
+
+func (c FixedPointConstraint) Project(bodies []BodyState, inverseMasses []float64) {
+	bodies[c.Body].X = c.Point.X
+	bodies[c.Body].Y = c.Point.Y
+	bodies[c.Body].Z = c.Point.Z
+}
+
+func (c FixedPointConstraint) Renumber(indices []int) (Constraint, bool) {
+	c.Body = indices[c.Body]
+	return c, c.Body >= 0
+}
+
+func (c FixedPointConstraint) GetPinnedBody() (int, bool) {
+	return c.Body, true
+}
+
+func (c FixedPointConstraint) Clone() Constraint {
+	return c
+}
+
+func projectDistance(bodies []BodyState, inverseMasses []float64, first, second int, length float64, slack bool) {
+	a := &bodies[first]
+	b := &bodies[second]
+	nx, ny, nz, distance := getDirection(a.X, a.Y, a.Z, b.X, b.Y, b.Z)
+	weight := inverseMasses[first] + inverseMasses[second]
+	if weight == 0 || (slack && distance <= length) {
+		return
+	}
+	correction := (distance - length) / weight
+	a.X += inverseMasses[first] * correction * nx
+	a.Y += inverseMasses[first] * correction * ny
+	a.Z += inverseMasses[first] * correction * nz
+	b.X -= inverseMasses[second] * correction * nx
+	b.Y -= inverseMasses[second] * correction * ny
+	b.Z -= inverseMasses[second] * correction * nz
+}
+
+// solveConstraints projects the bodies onto the constraints, adding the
+// displacement to their velocities. Pinned bodies are left at rest.
+func solveConstraints(bodies []BodyState, constraints []Constraint, settings Settings) {
+
+	if len(constraints) == 0 {
+		return
+	}
+
+	inverseMasses := make([]float64, len(bodies))
+	for i := range bodies {
+		inverseMasses[i] = 1 / bodies[i].GetMass()
+	}
+	for _, c := range constraints {
+		if body, ok := c.GetPinnedBody(); ok {
+			inverseMasses[body] = 0
+		}
+	}
+
+	predicted := append([]BodyState{}, bodies...)
+	for i := 0; i < settings.GetConstraintIterations(); i++ {
+		for _, c := range constraints {
+			c.Project(bodies, inverseMasses)
+		}
+	}
+
+	for i := range bodies {
+		b := &bodies[i]
+		if inverseMasses[i] == 0 {
+			b.VX, b.VY, b.VZ = 0, 0, 0
+			continue
+		}
+		// Without a time step there is no velocity to explain the movement
+		if settings.DeltaTime == 0 {
+			continue
+		}
+		b.VX += (b.X - predicted[i].X) / settings.DeltaTime
+		b.VY += (b.Y - predicted[i].Y) / settings.DeltaTime
+		b.VZ += (b.Z - predicted[i].Z) / settings.DeltaTime
+	}
+}
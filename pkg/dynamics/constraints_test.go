@@ -0,0 +1,122 @@
+package dynamics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rpagliuca/go-physics/pkg/algebra"
+	"github.com/stretchr/testify/assert"
+)
+
+func pendulumState(bodies ...BodyState) State {
+	settings := SETTINGS
+	settings.Boundary = UnboundedBoundary{}
+	settings.Integrator = RungeKuttaIntegrator{}
+	settings.DeltaTime = 0.001
+	settings.ConstraintIterations = 20
+	return State{
+		Settings: settings,
+		Bodies:   append([]BodyState{{X: 0, Y: 0}}, bodies...),
+		GravitySources: []GravitySource{
+			&LinearGravitySource{SETTINGS, algebra.Line{X0: -1, Y0: 1e9, X1: 1, Y1: 1e9}},
+		},
+		Constraints: []Constraint{
+			FixedPointConstraint{Body: 0, Point: algebra.Point3D{X: 0, Y: 0}},
+			DistanceConstraint{First: 0, Second: 1, Length: 10},
+		},
+	}
+}
+
+func TestDoublePendulum(t *testing.T) {
+
+	s := pendulumState(BodyState{X: 10, Y: 0, Mass: 2}, BodyState{X: 10, Y: 10, Mass: 1})
+	s.Constraints = append(s.Constraints, DistanceConstraint{First: 1, Second: 2, Length: 10})
+	energy := s.TotalEnergy()
+
+	for i := 0; i < 20000; i++ {
+		s = UpdateState(s)
+	}
+
+	a, b, c := s.Bodies[0], s.Bodies[1], s.Bodies[2]
+	assert.Equal(t, []float64{0, 0, 0, 0}, []float64{a.X, a.Y, a.VX, a.VY})
+	assert.InDelta(t, 10, math.Hypot(b.X-a.X, b.Y-a.Y), 1e-6)
+	assert.InDelta(t, 10, math.Hypot(c.X-b.X, c.Y-b.Y), 1e-6)
+	assert.InDelta(t, energy, s.TotalEnergy(), 0.01*math.Abs(energy))
+}
+
+func TestPendulumPeriod(t *testing.T) {
+
+	s := pendulumState(BodyState{X: 0.1, Y: 10})
+	period := 2 * math.Pi * math.Sqrt(10)
+
+	steps := int(math.Round(period / s.Settings.DeltaTime))
+	for i := 0; i < steps; i++ {
+		s = UpdateState(s)
+	}
+
+	assert.InDelta(t, 0.1, s.Bodies[1].X, 1e-3)
+}
+
+func TestRopeSlack(t *testing.T) {
+
+	s := pendulumState(BodyState{X: 0, Y: 5})
+	s.Constraints[1] = MaxDistanceConstraint{First: 0, Second: 1, Length: 10}
+
+	// Free fall while the rope is slack
+	for i := 0; i < 1000; i++ {
+		s = UpdateState(s)
+	}
+	assert.InDelta(t, 5.5, s.Bodies[1].Y, 1e-6)
+
+	// Then the rope holds the body
+	for i := 0; i < 5000; i++ {
+		s = UpdateState(s)
+	}
+	assert.InDelta(t, 10, s.Bodies[1].Y, 1e-9)
+	assert.InDelta(t, 0, s.Bodies[1].VY, s.Settings.DeltaTime)
+}
+
+func TestConstraintsRenumberedOnRemoval(t *testing.T) {
+
+	s := pendulumState(BodyState{X: 10, Y: 0}, BodyState{X: 1000})
+	s.Settings.Boundary = AbsorbingBoundary{MinX: -100, MinY: -100, MaxX: 100, MaxY: 100}
+	s.Bodies[0], s.Bodies[2] = s.Bodies[2], s.Bodies[0]
+	s.Constraints = []Constraint{
+		FixedPointConstraint{Body: 2, Point: algebra.Point3D{}},
+		DistanceConstraint{First: 2, Second: 1, Length: 10},
+		DistanceConstraint{First: 0, Second: 1, Length: 990},
+	}
+
+	s = UpdateState(s)
+
+	assert.Len(t, s.Bodies, 2)
+	assert.Equal(t, []Constraint{
+		FixedPointConstraint{Body: 1, Point: algebra.Point3D{}},
+		DistanceConstraint{First: 1, Second: 0, Length: 10},
+	}, s.Constraints)
+}
+
+func TestPointerPinIsAnchor(t *testing.T) {
+
+	s := pendulumState(BodyState{X: 10, Y: 0, Mass: 1})
+	s.Constraints[0] = &FixedPointConstraint{Body: 0, Point: algebra.Point3D{X: 0, Y: 0}}
+
+	for i := 0; i < 1000; i++ {
+		s = UpdateState(s)
+	}
+
+	assert.Equal(t, []float64{0, 0, 0, 0}, []float64{s.Bodies[0].X, s.Bodies[0].Y, s.Bodies[0].VX, s.Bodies[0].VY})
+	assert.InDelta(t, 10, math.Hypot(s.Bodies[1].X, s.Bodies[1].Y), 1e-6)
+}
+
+func TestConstraintsWithoutTimeStep(t *testing.T) {
+
+	s := pendulumState(BodyState{X: 20, Y: 0, VY: 3})
+	s.Settings.DeltaTime = 0
+
+	s = UpdateState(s)
+
+	// The body is moved back onto the rod, keeping its velocity
+	assert.InDelta(t, 10, s.Bodies[1].X, 1e-9)
+	assert.Equal(t, []float64{0, 3}, []float64{s.Bodies[1].VX, s.Bodies[1].VY})
+}
@@ -0,0 +1,73 @@
+package dynamics
+
+import "github.com/rpagliuca/go-physics/pkg/algebra"
+
+func (s State) KineticEnergy() float64 {
+	energy := 0.0
+	for _, b := range s.Bodies {
+		energy += b.GetMass() * (b.VX*b.VX + b.VY*b.VY + b.VZ*b.VZ) / 2
+	}
+	return energy
+}
+
+// PotentialEnergy sums the gravity, mutual, force element and electric
+// energies
+func (s State) PotentialEnergy() float64 {
+	energy := 0.0
+	for _, b := range s.Bodies {
+		for _, g := range s.GravitySources {
+			energy += g.GetPotentialEnergy(b)
+		}
+	}
+	for _, f := range s.Forces {
+		energy += f.GetPotentialEnergy(s.Bodies)
+	}
+	energy += getElectricPotentialEnergy(s.Bodies, s.ElectricFields, s.Settings)
+	return energy + getMutualPotentialEnergy(s.Bodies, s.Settings)
+}
+
+func (s State) TotalEnergy() float64 {
+	return s.KineticEnergy() + s.PotentialEnergy()
+}
+
+func (s State) LinearMomentum() (float64, float64, float64) {
+	px, py, pz := 0.0, 0.0, 0.0
+	for _, b := range s.Bodies {
+		px += b.GetMass() * b.VX
+		py += b.GetMass() * b.VY
+		pz += b.GetMass() * b.VZ
+	}
+	return px, py, pz
+}
+
+// AngularMomentum returns the angular momentum about the point. For
+// two-dimensional scenes only the last (out of plane) component is not zero.
+func (s State) AngularMomentum(about algebra.Point3D) (float64, float64, float64) {
+	lx, ly, lz := 0.0, 0.0, 0.0
+	for _, b := range s.Bodies {
+		m := b.GetMass()
+		x, y, z := b.X-about.X, b.Y-about.Y, b.Z-about.Z
+		lx += m * (y*b.VZ - z*b.VY)
+		ly += m * (z*b.VX - x*b.VZ)
+		lz += m * (x*b.VY - y*b.VX)
+	}
+	return lx, ly, lz
+}
+
+func (s State) CenterOfMass() algebra.Point3D {
+	mass := 0.0
+	center := algebra.Point3D{}
+	for _, b := range s.Bodies {
+		mass += b.GetMass()
+		center.X += b.GetMass() * b.X
+		center.Y += b.GetMass() * b.Y
+		center.Z += b.GetMass() * b.Z
+	}
+	if mass == 0 {
+		return center
+	}
+	center.X /= mass
+	center.Y /= mass
+	center.Z /= mass
+	return center
+}
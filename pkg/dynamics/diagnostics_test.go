@@ -0,0 +1,110 @@
+package dynamics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rpagliuca/go-physics/pkg/algebra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPotentialEnergyGradientMatchesAcceleration(t *testing.T) {
+
+	sources := []GravitySource{
+		&LinearGravitySource{SETTINGS, algebra.Line{X0: 0, Y0: 0, X1: 3, Y1: 1}},
+		&PointGravitySource{Settings: SETTINGS, Point: algebra.Point3D{X: 5, Y: 5, Z: -4}, Mode: ConstantMagnitudeGravity},
+		&PointGravitySource{Settings: SETTINGS, Point: algebra.Point3D{X: 5, Y: 5, Z: -4}, GM: 1000, Softening: 2},
+		&PointGravitySource{Settings: SETTINGS, Point: algebra.Point3D{X: 5, Y: 5}, GM: 1000, Repulsive: true},
+		&PlaneGravitySource{SETTINGS, algebra.Plane{Normal: algebra.Point3D{X: 1, Y: -2, Z: 2}}},
+	}
+
+	const h = 1e-6
+	b := BodyState{X: 12, Y: 30, Z: 7, Mass: 2}
+
+	gradient := func(g GravitySource, dx, dy, dz float64) float64 {
+		after := b
+		after.X, after.Y, after.Z = b.X+dx, b.Y+dy, b.Z+dz
+		before := b
+		before.X, before.Y, before.Z = b.X-dx, b.Y-dy, b.Z-dz
+		return (g.GetPotentialEnergy(after) - g.GetPotentialEnergy(before)) / (2 * h)
+	}
+
+	for _, g := range sources {
+		acceleration := g.GetAcceleration(b)
+		assert.InDelta(t, acceleration.AX, -gradient(g, h, 0, 0)/b.GetMass(), 1e-6)
+		assert.InDelta(t, acceleration.AY, -gradient(g, 0, h, 0)/b.GetMass(), 1e-6)
+		assert.InDelta(t, acceleration.AZ, -gradient(g, 0, 0, h)/b.GetMass(), 1e-6)
+	}
+}
+
+func TestStateDiagnostics(t *testing.T) {
+
+	settings := SETTINGS
+	settings.GravitationalConstant = 2
+
+	s := State{
+		Settings: settings,
+		Bodies: []BodyState{
+			{X: 0, Y: 0, VX: 1, VY: 0, Mass: 1},
+			{X: 4, Y: 3, VX: 0, VY: -2, Mass: 3},
+		},
+		GravitySources: []GravitySource{
+			&LinearGravitySource{settings, algebra.Line{X0: 0, Y0: -1, X1: 1, Y1: -1}},
+		},
+	}
+
+	assert.Equal(t, 0.5+6, s.KineticEnergy())
+	// Fields: 1 * 1 * 1 + 3 * 1 * 4, mutual: -2 * 1 * 3 / 5
+	assert.InDelta(t, 13-1.2, s.PotentialEnergy(), 1e-12)
+	assert.InDelta(t, 6.5+13-1.2, s.TotalEnergy(), 1e-12)
+
+	px, py, pz := s.LinearMomentum()
+	assert.Equal(t, []float64{1, -6, 0}, []float64{px, py, pz})
+
+	lx, ly, lz := s.AngularMomentum(algebra.Point3D{})
+	assert.Equal(t, []float64{0, 0, -24}, []float64{lx, ly, lz})
+	_, _, lz = s.AngularMomentum(algebra.Point3D{X: 4, Y: 3})
+	assert.Equal(t, 3.0, lz)
+
+	assert.Equal(t, algebra.Point3D{X: 3, Y: 2.25}, s.CenterOfMass())
+}
+
+func TestIntegratorsConserveEnergy(t *testing.T) {
+
+	settings := SETTINGS
+	settings.GravitationalConstant = 1
+
+	s := State{
+		Settings: settings,
+		Bodies: []BodyState{
+			{X: -25, Y: 0, VY: -0.05, Mass: 3},
+			{X: 75, Y: 0, VY: 0.15, Mass: 1},
+		},
+		GravitySources: []GravitySource{},
+	}
+
+	cases := []struct {
+		integrator Integrator
+		tolerance  float64
+	}{
+		{FrozenRungeKuttaIntegrator{}, 1e-2},
+		{EulerIntegrator{}, 1e-1},
+		{SemiImplicitEulerIntegrator{}, 1e-2},
+		{VelocityVerletIntegrator{}, 1e-4},
+		{LeapfrogIntegrator{}, 1e-4},
+		{BorisIntegrator{}, 1e-4},
+		{YoshidaIntegrator{}, 1e-6},
+		{RungeKuttaIntegrator{}, 1e-8},
+		{&DormandPrinceIntegrator{}, 1e-6},
+	}
+
+	for _, c := range cases {
+		state := s.Clone()
+		initial := state.TotalEnergy()
+		for i := 0; i < 1000; i++ {
+			state.Bodies = c.integrator.Step(state.Bodies, 1, state)
+		}
+		drift := math.Abs((state.TotalEnergy() - initial) / initial)
+		assert.Less(t, drift, c.tolerance, "%T", c.integrator)
+	}
+}
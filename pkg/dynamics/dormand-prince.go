@@ -0,0 +1,159 @@
+package dynamics
+
+import "math"
+
+// Dormand-Prince 5(4) Butcher tableau
+var (
+	dormandPrinceA = [][]float64{
+		{},
+		{1.0 / 5},
+		{3.0 / 40, 9.0 / 40},
+		{44.0 / 45, -56.0 / 15, 32.0 / 9},
+		{19372.0 / 6561, -25360.0 / 2187, 64448.0 / 6561, -212.0 / 729},
+		{9017.0 / 3168, -355.0 / 33, 46732.0 / 5247, 49.0 / 176, -5103.0 / 18656},
+		{35.0 / 384, 0, 500.0 / 1113, 125.0 / 192, -2187.0 / 6784, 11.0 / 84},
+	}
+	// Difference between the fifth and the embedded fourth order solutions
+	dormandPrinceE = []float64{
+		71.0 / 57600, 0, -71.0 / 16695, 71.0 / 1920, -17253.0 / 339200, 22.0 / 525, -1.0 / 40,
+	}
+)
+
+const DEFAULT_TOLERANCE = 1e-6
+
+// DormandPrinceIntegrator is an adaptive fifth order Runge-Kutta method,
+// sub-stepping within the tolerances. It keeps state, so use it by pointer.
+type DormandPrinceIntegrator struct {
+	// Both tolerances default to DEFAULT_TOLERANCE when unset
+	AbsoluteTolerance float64
+	RelativeTolerance float64
+
+	// Statistics of the last call to Step
+	SubSteps      int
+	RejectedSteps int
+
+	nextStep float64
+}
+
+func (d *DormandPrinceIntegrator) getTolerances() (float64, float64) {
+	absolute := d.AbsoluteTolerance
+	if absolute == 0 {
+		absolute = DEFAULT_TOLERANCE
+	}
+	relative := d.RelativeTolerance
+	if relative == 0 {
+		relative = DEFAULT_TOLERANCE
+	}
+	return absolute, relative
+}
+
+func (d *DormandPrinceIntegrator) Step(bodies []BodyState, deltaTime float64, system System) []BodyState {
+
+	d.SubSteps = 0
+	d.RejectedSteps = 0
+
+	if deltaTime == 0 {
+		return advance(bodies, 0, nil)
+	}
+
+	h := d.nextStep
+	if h <= 0 {
+		h = math.Abs(deltaTime)
+	}
+	initialStep := h
+	direction := math.Copysign(1, deltaTime)
+	minStep := math.Abs(deltaTime) * 1e-12
+
+	remaining := math.Abs(deltaTime)
+	current := bodies
+	k1 := getDerivatives(current, system)
+
+	for remaining > 0 {
+		step := math.Min(h, remaining)
+
+		next, k7, errorNorm := d.attempt(current, direction*step, k1, system)
+
+		// Non-finite accelerations reject the step as if its error were too
+		// large. Once the step cannot shrink, the rest of deltaTime is taken
+		// in one go, keeping the step size of the call.
+		if math.IsNaN(errorNorm) || math.IsInf(errorNorm, 0) {
+			if step > minStep {
+				d.RejectedSteps++
+				h = step * 0.2
+				continue
+			}
+			current, _, _ = d.attempt(current, direction*remaining, k1, system)
+			d.SubSteps++
+			h = initialStep
+			break
+		}
+
+		factor := 5.0
+		if errorNorm > 0 {
+			factor = math.Min(5, math.Max(0.2, 0.9*math.Pow(errorNorm, -0.2)))
+		}
+
+		if errorNorm > 1 && step > minStep {
+			d.RejectedSteps++
+			h = step * factor
+			continue
+		}
+
+		d.SubSteps++
+		current = next
+		k1 = k7 // First same as last
+		if step >= remaining-minStep {
+			remaining = 0
+		} else {
+			remaining -= step
+		}
+
+		// A step shortened to land exactly on deltaTime must not inflate
+		// the step size used next
+		if step == h || factor < 1 {
+			h = step * factor
+		}
+	}
+
+	d.nextStep = h
+
+	return current
+}
+
+// attempt takes a single Dormand-Prince step of size h, returning the new
+// bodies, the derivatives at them and the scaled error norm
+func (d *DormandPrinceIntegrator) attempt(bodies []BodyState, h float64, k1 []derivative, system System) ([]BodyState, []derivative, float64) {
+
+	// The last row of the tableau gives the fifth order solution itself
+	var next []BodyState
+	stages := [][]derivative{k1}
+	for s := 1; s < len(dormandPrinceA); s++ {
+		next = advance(bodies, h, dormandPrinceA[s], stages...)
+		stages = append(stages, getDerivatives(next, system))
+	}
+	errors := advance(make([]BodyState, len(bodies)), h, dormandPrinceE, stages...)
+
+	absolute, relative := d.getTolerances()
+	sum := 0.0
+	count := 0
+	for i := range bodies {
+		components := [][3]float64{
+			{errors[i].X, bodies[i].X, next[i].X},
+			{errors[i].Y, bodies[i].Y, next[i].Y},
+			{errors[i].Z, bodies[i].Z, next[i].Z},
+			{errors[i].VX, bodies[i].VX, next[i].VX},
+			{errors[i].VY, bodies[i].VY, next[i].VY},
+			{errors[i].VZ, bodies[i].VZ, next[i].VZ},
+		}
+		for _, c := range components {
+			scale := absolute + relative*math.Max(math.Abs(c[1]), math.Abs(c[2]))
+			sum += (c[0] / scale) * (c[0] / scale)
+			count++
+		}
+	}
+	if count == 0 {
+		return next, stages[len(stages)-1], 0
+	}
+
+	return next, stages[len(stages)-1], math.Sqrt(sum / float64(count))
+}
@@ -0,0 +1,100 @@
+package dynamics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Inverse-square attraction towards the origin, with GM = 1
+var keplerProblem = AccelerationFunc(func(bodies []BodyState) []Acceleration {
+	accelerations := make([]Acceleration, len(bodies))
+	for i, b := range bodies {
+		r := math.Sqrt(b.X*b.X + b.Y*b.Y + b.Z*b.Z)
+		accelerations[i] = Acceleration{-b.X / (r * r * r), -b.Y / (r * r * r), -b.Z / (r * r * r)}
+	}
+	return accelerations
+})
+
+func keplerPeriodError(integrator Integrator, steps int) float64 {
+	// Eccentricity 0.9, starting at the periapsis, semi-major axis 1
+	bodies := []BodyState{{X: 0.1, VY: math.Sqrt(19)}}
+	deltaTime := 2 * math.Pi / float64(steps)
+	for i := 0; i < steps; i++ {
+		bodies = integrator.Step(bodies, deltaTime, keplerProblem)
+	}
+	return math.Hypot(bodies[0].X-0.1, bodies[0].Y)
+}
+
+func TestDormandPrinceCloseEncounter(t *testing.T) {
+
+	integrator := &DormandPrinceIntegrator{AbsoluteTolerance: 1e-9, RelativeTolerance: 1e-9}
+
+	adaptiveError := keplerPeriodError(integrator, 60)
+	fixedError := keplerPeriodError(RungeKuttaIntegrator{}, 60)
+
+	assert.Less(t, adaptiveError, 1e-5)
+	assert.Greater(t, fixedError, 1e-2)
+}
+
+func TestDormandPrinceReportsSubSteps(t *testing.T) {
+
+	integrator := &DormandPrinceIntegrator{AbsoluteTolerance: 1e-8, RelativeTolerance: 1e-8}
+	bodies := []BodyState{{X: 0.1, VY: math.Sqrt(19)}}
+
+	// A single large step through the periapsis is split and partially rejected
+	integrator.Step(bodies, 0.5, keplerProblem)
+	assert.Greater(t, integrator.SubSteps, 1)
+	assert.Greater(t, integrator.RejectedSteps, 0)
+
+	// Far from the periapsis the step size adapted above is enough
+	far := []BodyState{{X: -1.9, VY: -math.Sqrt(1.0 / 19)}}
+	integrator.Step(far, 0.001, keplerProblem)
+	assert.Equal(t, 1, integrator.SubSteps)
+	assert.Equal(t, 0, integrator.RejectedSteps)
+}
+
+func TestDormandPrinceAdvancesExactlyDeltaTime(t *testing.T) {
+
+	integrator := &DormandPrinceIntegrator{}
+	constant := AccelerationFunc(func(bodies []BodyState) []Acceleration {
+		return []Acceleration{{0, -3, 0}}
+	})
+
+	bodies := integrator.Step([]BodyState{{X: 500, Y: 500, VX: 1, VY: 2}}, 2, constant)
+
+	assert.InDelta(t, 502.0, bodies[0].X, 1e-9)
+	assert.InDelta(t, 498.0, bodies[0].Y, 1e-9)
+	assert.InDelta(t, -4.0, bodies[0].VY, 1e-9)
+}
+
+func TestDormandPrinceNonFiniteError(t *testing.T) {
+
+	// The first attempted step meets a NaN acceleration and is retried
+	calls := 0
+	glitch := AccelerationFunc(func(bodies []BodyState) []Acceleration {
+		calls++
+		if calls == 2 {
+			return []Acceleration{{math.NaN(), 0, 0}}
+		}
+		return []Acceleration{{0, -3, 0}}
+	})
+	integrator := &DormandPrinceIntegrator{}
+	bodies := integrator.Step([]BodyState{{VX: 1}}, 2, glitch)
+
+	assert.Equal(t, 1, integrator.RejectedSteps)
+	assert.InDelta(t, 2.0, bodies[0].X, 1e-9)
+	assert.InDelta(t, -6.0, bodies[0].Y, 1e-9)
+	assert.Equal(t, 2.0, integrator.nextStep)
+
+	// Accelerations which are never finite end the step without losing the
+	// step size
+	broken := AccelerationFunc(func(bodies []BodyState) []Acceleration {
+		return []Acceleration{{math.NaN(), 0, 0}}
+	})
+	integrator.Step(bodies, 1, broken)
+
+	assert.Equal(t, 1, integrator.SubSteps)
+	assert.Equal(t, 2.0, integrator.nextStep)
+}
@@ -1,23 +1,104 @@
+// Package dynamics simulates bodies under gravity, forces, constraints and
+// electromagnetic fields. Every quantity is in SI units: metres, seconds,
+// kilograms and coulombs. Drawing the bodies on a screen is left to
+// viewport.Viewport.
 package dynamics
 
+import "github.com/rpagliuca/go-physics/pkg/algebra"
+
 const BOUNCING_CONSERVATION = 0.3
 
+// NO_RESTITUTION is the restitution of bodies and walls that stop on impact.
+// Optional fields of bodies and boundaries, such as masses, radii and
+// restitutions, take their default when zero, so zero restitution needs its
+// own value.
+const NO_RESTITUTION = -1.0
+
 func getAcceleration(bodyState BodyState, gravitySources []GravitySource) Acceleration {
-	acceleration := Acceleration{0, 0}
+	acceleration := Acceleration{0, 0, 0}
 	for i := range gravitySources {
 		newAcceleration := gravitySources[i].GetAcceleration(bodyState)
 		acceleration.AX += newAcceleration.AX
 		acceleration.AY += newAcceleration.AY
+		acceleration.AZ += newAcceleration.AZ
 	}
 	return acceleration
 }
 
-func UpdateState(state State) State {
-	for i := range state.Bodies {
-		bodyState := state.Bodies[i]
-		acceleration := getAcceleration(bodyState, state.GravitySources)
-		nextState := getNextBodyStateRungeKutta(bodyState, acceleration, state.Settings)
-		state.Bodies[i] = nextState
+// GetAccelerations makes State a System, evaluating the accelerations of the
+// given bodies under the gravity sources, fields and settings of the state
+func (s State) GetAccelerations(bodies []BodyState) []Acceleration {
+	accelerations := s.GetNonMagneticAccelerations(bodies)
+	addMagneticForces(bodies, accelerations, s.MagneticField)
+	return accelerations
+}
+
+func (s State) GetMagneticField() algebra.Point3D {
+	return s.MagneticField
+}
+
+func (s State) GetNonMagneticAccelerations(bodies []BodyState) []Acceleration {
+	accelerations := make([]Acceleration, len(bodies))
+	parallelFor(len(bodies), s.Settings.GetWorkers(), func(i int) {
+		accelerations[i] = getAcceleration(bodies[i], s.GravitySources)
+		accelerations[i].AX -= s.Settings.Drag * bodies[i].VX
+		accelerations[i].AY -= s.Settings.Drag * bodies[i].VY
+		accelerations[i].AZ -= s.Settings.Drag * bodies[i].VZ
+	})
+	for _, f := range s.Forces {
+		f.AddAccelerations(bodies, accelerations)
 	}
+	addElectricForces(bodies, accelerations, s.ElectricFields, s.Settings)
+	addMutualGravity(bodies, accelerations, s.Settings)
+	addCoulombForces(bodies, accelerations, s.Settings)
+	return accelerations
+}
+
+func UpdateState(state State) State {
+	state.Bodies = state.Settings.GetIntegrator().Step(state.Bodies, state.Settings.DeltaTime, state)
+	solveConstraints(state.Bodies, state.Constraints, state.Settings)
+	removed, hits := applyBoundary(state.Bodies, state.Settings)
+	state.Events.emitBoundaryHits(hits)
+	state.removeBodies(removed)
+	state.Collisions, removed = resolveCollisions(state.Bodies, state.Settings)
+	state.Events.emitCollisions(state.Collisions)
+	state.removeBodies(removed)
+	state.Time += state.Settings.DeltaTime
+	state.Step++
+	state.Events.emitStep(state)
 	return state
 }
+
+// removeBodies drops the flagged bodies, renumbering or dropping the force
+// elements and constraints referencing them
+func (s *State) removeBodies(removed []bool) {
+	indices := make([]int, len(s.Bodies))
+	kept := s.Bodies[:0]
+	for i := range s.Bodies {
+		if removed[i] {
+			indices[i] = -1
+			s.Events.emitBodyRemoved(i, s.Bodies[i])
+			continue
+		}
+		indices[i] = len(kept)
+		kept = append(kept, s.Bodies[i])
+	}
+	if len(kept) == len(s.Bodies) {
+		return
+	}
+	s.Bodies = kept
+	forces := []ForceElement{}
+	for _, f := range s.Forces {
+		if f, keep := f.Renumber(indices); keep {
+			forces = append(forces, f)
+		}
+	}
+	s.Forces = forces
+	constraints := []Constraint{}
+	for _, c := range s.Constraints {
+		if c, keep := c.Renumber(indices); keep {
+			constraints = append(constraints, c)
+		}
+	}
+	s.Constraints = constraints
+}
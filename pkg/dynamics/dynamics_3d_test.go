@@ -0,0 +1,110 @@
+package dynamics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rpagliuca/go-physics/pkg/algebra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInclinedOrbitStaysInItsPlane(t *testing.T) {
+
+	settings := SETTINGS
+	settings.Boundary = UnboundedBoundary{}
+	settings.Integrator = RungeKuttaIntegrator{}
+	settings.GravitationalConstant = 1
+
+	// Circular binary orbit, in a plane inclined by 30 degrees around X
+	cos, sin := math.Cos(math.Pi/6), math.Sin(math.Pi/6)
+	s := State{
+		Settings: settings,
+		Bodies: []BodyState{
+			{X: -25, VY: -0.05 * cos, VZ: -0.05 * sin, Mass: 3},
+			{X: 75, VY: 0.15 * cos, VZ: 0.15 * sin, Mass: 1},
+		},
+	}
+	lx0, ly0, lz0 := s.AngularMomentum(algebra.Point3D{})
+
+	maxZ := 0.0
+	for i := 0; i < 1000; i++ {
+		s = UpdateState(s)
+		maxZ = math.Max(maxZ, s.Bodies[1].Z)
+		// Every position stays in the orbital plane
+		for _, b := range s.Bodies {
+			assert.InDelta(t, 0.0, -sin*b.Y+cos*b.Z, 1e-9)
+		}
+	}
+
+	assert.InDelta(t, 75*sin, maxZ, 1)
+	lx, ly, lz := s.AngularMomentum(algebra.Point3D{})
+	assert.InDelta(t, lx0, lx, 1e-9)
+	assert.InDelta(t, ly0, ly, 1e-9)
+	assert.InDelta(t, lz0, lz, 1e-9)
+	distance := math.Sqrt(math.Pow(s.Bodies[1].X-s.Bodies[0].X, 2) +
+		math.Pow(s.Bodies[1].Y-s.Bodies[0].Y, 2) +
+		math.Pow(s.Bodies[1].Z-s.Bodies[0].Z, 2))
+	assert.InDelta(t, 100.0, distance, 0.01)
+}
+
+func TestPlaneGravitySource(t *testing.T) {
+
+	settings := SETTINGS
+	settings.Boundary = UnboundedBoundary{}
+
+	s := State{
+		Settings: settings,
+		Bodies: []BodyState{
+			{X: 5, Y: 10, Z: 20, VX: 1},
+			{X: 5, Y: 10, Z: -20},
+		},
+		GravitySources: []GravitySource{
+			&PlaneGravitySource{settings, algebra.Plane{Normal: algebra.Point3D{Z: 1}}},
+		},
+	}
+
+	s = UpdateState(s)
+
+	assert.Equal(t, BodyState{X: 6, Y: 10, Z: 19.5, VX: 1, VZ: -1}, s.Bodies[0])
+	assert.Equal(t, BodyState{X: 5, Y: 10, Z: -19.5, VZ: 1}, s.Bodies[1])
+
+	// Infinite planes report no width, like points, rather than an infinite one
+	assert.Equal(t, 0.0, s.GravitySources[0].GetWidth())
+}
+
+func TestPointGravitySourceAboveThePlane(t *testing.T) {
+
+	p := &PointGravitySource{Settings: SETTINGS, Point: algebra.Point3D{X: 1, Y: 2, Z: 3}}
+
+	assert.Equal(t, []float64{3, 3}, []float64{p.GetZ(), p.GetOtherZ()})
+
+	// Dragging the source keeps its height
+	p.UpdateCenter(4, 5)
+	assert.Equal(t, algebra.Point3D{X: 4, Y: 5, Z: 3}, p.Point)
+}
+
+func TestThreeDimensionalCollisionAndWalls(t *testing.T) {
+
+	settings := SETTINGS
+	settings.DeltaTime = 0.1
+	settings.CollisionResponse = ElasticCollisions
+	settings.Boundary = ReflectiveBoundary{MaxX: 100, MaxY: 100, MinZ: -10, MaxZ: 10, Restitution: 1}
+
+	s := State{
+		Settings: settings,
+		Bodies: []BodyState{
+			{X: 50, Y: 50, Z: 0, VZ: 1, Radius: 1},
+			{X: 50, Y: 50, Z: 2.1, VZ: -1, Radius: 1},
+			{X: 20, Y: 20, Z: 8.5, VZ: 10, Radius: 1},
+		},
+	}
+
+	s = UpdateState(s)
+
+	assert.Len(t, s.Collisions, 1)
+	assert.Equal(t, 1.0, s.Collisions[0].NormalZ)
+	assert.InDelta(t, -1.0, s.Bodies[0].VZ, 1e-12)
+	assert.InDelta(t, 1.0, s.Bodies[1].VZ, 1e-12)
+	assert.Equal(t, 9.0, s.Bodies[2].Z)
+	assert.Equal(t, -10.0, s.Bodies[2].VZ)
+}
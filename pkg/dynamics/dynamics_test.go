@@ -10,16 +10,15 @@ import (
 var SETTINGS = Settings{
 	GravityAcceleration: 1,
 	DeltaTime:           1,
-	ViewportHeight:      1000,
-	ViewportWidth:       1000,
-	ViewportBoxSize:     10,
+	DefaultRadius:       5,
+	Boundary:            ReflectiveBoundary{MaxX: 1000, MaxY: 1000, Restitution: BOUNCING_CONSERVATION, Friction: 0.05},
 }
 
 func TestLinearGravitySource(t *testing.T) {
 
 	s0 := State{
-		SETTINGS,
-		[]BodyState{
+		Settings: SETTINGS,
+		Bodies: []BodyState{
 			{
 				X:  5,
 				Y:  10,
@@ -27,7 +26,7 @@ func TestLinearGravitySource(t *testing.T) {
 				VY: 0,
 			},
 		},
-		[]GravitySource{
+		GravitySources: []GravitySource{
 			&LinearGravitySource{SETTINGS, algebra.Line{0, 0, 10, 0}},
 		},
 	}
@@ -41,8 +40,8 @@ func TestLinearGravitySource(t *testing.T) {
 func TestPointGravitySource(t *testing.T) {
 
 	s0 := State{
-		SETTINGS,
-		[]BodyState{
+		Settings: SETTINGS,
+		Bodies: []BodyState{
 			{
 				X:  5,
 				Y:  10,
@@ -50,8 +49,8 @@ func TestPointGravitySource(t *testing.T) {
 				VY: 0,
 			},
 		},
-		[]GravitySource{
-			&PointGravitySource{SETTINGS, algebra.Point{5, 5}},
+		GravitySources: []GravitySource{
+			&PointGravitySource{Settings: SETTINGS, Point: algebra.Point3D{X: 5, Y: 5}, Mode: ConstantMagnitudeGravity},
 		},
 	}
 
@@ -0,0 +1,152 @@
+package dynamics
+
+import (
+	"math"
+
+	"github.com/rpagliuca/go-physics/pkg/algebra"
+)
+
+// ElectricField acts on the charge of the bodies, adding the acceleration
+// Charge * E / Mass. Fields are evaluated concurrently for different bodies.
+type ElectricField interface {
+	GetField(x, y, z float64) algebra.Point3D
+	// GetPotential is the electric potential, so that a body has the energy
+	// Charge times the potential
+	GetPotential(x, y, z float64) float64
+	Clone() ElectricField
+}
+
+// UniformElectricField has the same field everywhere, with zero potential at
+// the origin
+type UniformElectricField struct {
+	Field algebra.Point3D
+}
+
+func (u UniformElectricField) GetField(x, y, z float64) algebra.Point3D {
+	return u.Field
+}
+
+func (u UniformElectricField) GetPotential(x, y, z float64) float64 {
+	return -(u.Field.X*x + u.Field.Y*y + u.Field.Z*z)
+}
+
+func (u UniformElectricField) Clone() ElectricField {
+	return u
+}
+
+// ElectricFieldFunc is a user defined electric field. The potential is
+// optional, and the field is left out of the potential energy without it.
+type ElectricFieldFunc struct {
+	Field     func(x, y, z float64) algebra.Point3D
+	Potential func(x, y, z float64) float64
+}
+
+func (f ElectricFieldFunc) GetField(x, y, z float64) algebra.Point3D {
+	return f.Field(x, y, z)
+}
+
+func (f ElectricFieldFunc) GetPotential(x, y, z float64) float64 {
+	if f.Potential == nil {
+		return 0
+	}
+	return f.Potential(x, y, z)
+}
+
+func (f ElectricFieldFunc) Clone() ElectricField {
+	return f
+}
+
+// MagneticSystem is a System with a uniform magnetic field, for integrators
+// such as BorisIntegrator
+type MagneticSystem interface {
+	System
+	GetMagneticField() algebra.Point3D
+	// GetNonMagneticAccelerations is GetAccelerations without the magnetic
+	// force
+	GetNonMagneticAccelerations(bodies []BodyState) []Acceleration
+}
+
+// addElectricForces adds the force of the electric fields on the charged
+// bodies
+func addElectricForces(bodies []BodyState, accelerations []Acceleration, fields []ElectricField, settings Settings) {
+	if len(fields) == 0 {
+		return
+	}
+	parallelFor(len(bodies), settings.GetWorkers(), func(i int) {
+		q := bodies[i].Charge
+		if q == 0 {
+			return
+		}
+		for _, f := range fields {
+			e := f.GetField(bodies[i].X, bodies[i].Y, bodies[i].Z)
+			addForce(bodies, accelerations, i, q*e.X, q*e.Y, q*e.Z)
+		}
+	})
+}
+
+// addMagneticForces adds the force Charge * v x B on the charged bodies
+func addMagneticForces(bodies []BodyState, accelerations []Acceleration, b algebra.Point3D) {
+	for i := range bodies {
+		q := bodies[i].Charge
+		if q == 0 {
+			continue
+		}
+		fx, fy, fz := cross(bodies[i].VX, bodies[i].VY, bodies[i].VZ, b.X, b.Y, b.Z)
+		addForce(bodies, accelerations, i, q*fx, q*fy, q*fz)
+	}
+}
+
+// addCoulombForces adds the electrostatic force between each pair of charged
+// bodies, softened like the mutual attraction
+func addCoulombForces(bodies []BodyState, accelerations []Acceleration, settings Settings) {
+	if settings.CoulombConstant == 0 {
+		return
+	}
+	parallelFor(len(bodies), settings.GetWorkers(), func(i int) {
+		if bodies[i].Charge == 0 {
+			return
+		}
+		for j := range bodies {
+			if j == i || bodies[j].Charge == 0 {
+				continue
+			}
+			dx := bodies[i].X - bodies[j].X
+			dy := bodies[i].Y - bodies[j].Y
+			dz := bodies[i].Z - bodies[j].Z
+			distance2 := dx*dx + dy*dy + dz*dz + settings.Softening*settings.Softening
+			if distance2 == 0 {
+				continue
+			}
+			// Pointing away from the other body when they repel each other
+			factor := settings.CoulombConstant * bodies[i].Charge * bodies[j].Charge / (distance2 * math.Sqrt(distance2))
+			addForce(bodies, accelerations, i, factor*dx, factor*dy, factor*dz)
+		}
+	})
+}
+
+func getElectricPotentialEnergy(bodies []BodyState, fields []ElectricField, settings Settings) float64 {
+	energy := 0.0
+	for i := range bodies {
+		for _, f := range fields {
+			energy += bodies[i].Charge * f.GetPotential(bodies[i].X, bodies[i].Y, bodies[i].Z)
+		}
+		if settings.CoulombConstant == 0 {
+			continue
+		}
+		for j := i + 1; j < len(bodies); j++ {
+			dx := bodies[j].X - bodies[i].X
+			dy := bodies[j].Y - bodies[i].Y
+			dz := bodies[j].Z - bodies[i].Z
+			distance := math.Sqrt(dx*dx + dy*dy + dz*dz + settings.Softening*settings.Softening)
+			if distance == 0 {
+				continue
+			}
+			energy += settings.CoulombConstant * bodies[i].Charge * bodies[j].Charge / distance
+		}
+	}
+	return energy
+}
+
+func cross(ax, ay, az, bx, by, bz float64) (float64, float64, float64) {
+	return ay*bz - az*by, az*bx - ax*bz, ax*by - ay*bx
+}
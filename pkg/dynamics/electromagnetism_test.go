@@ -0,0 +1,140 @@
+package dynamics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rpagliuca/go-physics/pkg/algebra"
+	"github.com/stretchr/testify/assert"
+)
+
+func cyclotronState(b float64) State {
+	settings := SETTINGS
+	settings.Boundary = UnboundedBoundary{}
+	settings.Integrator = BorisIntegrator{}
+	settings.DeltaTime = 0.01
+	return State{
+		Settings:      settings,
+		Bodies:        []BodyState{{X: 0, Y: 0, VX: 3, Mass: 2, Charge: 0.5}},
+		MagneticField: algebra.Point3D{Z: b},
+	}
+}
+
+func TestCyclotronRadius(t *testing.T) {
+
+	s := cyclotronState(4)
+	energy := s.TotalEnergy()
+
+	// r = m v / (q B), centred below the start for a positive charge
+	radius := 2 * 3 / (0.5 * 4.0)
+	minY, maxY := 0.0, 0.0
+	for i := 0; i < 10000; i++ {
+		s = UpdateState(s)
+		b := s.Bodies[0]
+		assert.InDelta(t, radius, math.Hypot(b.X, b.Y+radius), 1e-3)
+		minY = math.Min(minY, b.Y)
+		maxY = math.Max(maxY, b.Y)
+	}
+
+	assert.InDelta(t, 2*radius, maxY-minY, 1e-3)
+	assert.InDelta(t, energy, s.TotalEnergy(), 1e-12)
+}
+
+func TestCyclotronPeriod(t *testing.T) {
+
+	s := cyclotronState(4)
+	period := 2 * math.Pi * 2 / (0.5 * 4)
+
+	steps := int(math.Round(period / s.Settings.DeltaTime))
+	for i := 0; i < steps; i++ {
+		s = UpdateState(s)
+	}
+
+	assert.InDelta(t, 0, s.Bodies[0].X, 0.01)
+	assert.InDelta(t, 0, s.Bodies[0].Y, 0.01)
+}
+
+func TestNeutralBodiesIgnoreFields(t *testing.T) {
+
+	s := cyclotronState(4)
+	s.Bodies[0].Charge = 0
+	s.ElectricFields = []ElectricField{UniformElectricField{Field: algebra.Point3D{Y: 1}}}
+
+	s = UpdateState(s)
+
+	assert.InDelta(t, 0.03, s.Bodies[0].X, 1e-12)
+	assert.Equal(t, 0.0, s.Bodies[0].Y)
+}
+
+func TestExBDrift(t *testing.T) {
+
+	s := cyclotronState(4)
+	s.Bodies[0].VX = 0
+	s.ElectricFields = []ElectricField{UniformElectricField{Field: algebra.Point3D{Y: 2}}}
+	energy := s.TotalEnergy()
+
+	// The guiding centre drifts with E x B / B² along X
+	period := 2 * math.Pi * 2 / (0.5 * 4)
+	steps := int(math.Round(period / s.Settings.DeltaTime))
+	for i := 0; i < 10*steps; i++ {
+		s = UpdateState(s)
+	}
+
+	assert.InDelta(t, 2.0/4*10*period, s.Bodies[0].X, 0.05)
+	assert.InDelta(t, energy, s.TotalEnergy(), 1e-3)
+}
+
+func TestElectricFieldFunc(t *testing.T) {
+
+	// Harmonic well for the charge, E = -x
+	s := cyclotronState(0)
+	s.Bodies[0].VX = 1
+	s.ElectricFields = []ElectricField{ElectricFieldFunc{
+		Field: func(x, y, z float64) algebra.Point3D {
+			return algebra.Point3D{X: -x, Y: -y, Z: -z}
+		},
+		Potential: func(x, y, z float64) float64 {
+			return (x*x + y*y + z*z) / 2
+		},
+	}}
+	energy := s.TotalEnergy()
+
+	for i := 0; i < 1000; i++ {
+		s = UpdateState(s)
+	}
+
+	assert.InDelta(t, energy, s.TotalEnergy(), 1e-4)
+	assert.Less(t, math.Abs(s.Bodies[0].X), 2.1)
+}
+
+func TestCoulombInteraction(t *testing.T) {
+
+	settings := SETTINGS
+	settings.Boundary = UnboundedBoundary{}
+	settings.Integrator = RungeKuttaIntegrator{}
+	settings.DeltaTime = 0.01
+	settings.CoulombConstant = 2
+	s := State{
+		Settings: settings,
+		Bodies: []BodyState{
+			{X: 0, Y: 0, VX: 0.5, Mass: 1, Charge: 1},
+			{X: 3, Y: 0.5, Mass: 2, Charge: 1},
+			{X: 0, Y: 4, Mass: 1, Charge: -2},
+		},
+	}
+
+	accelerations := s.GetAccelerations(s.Bodies)
+	// Like charges repel, opposite charges attract
+	assert.Less(t, accelerations[0].AX, 0.0)
+	assert.Less(t, accelerations[2].AY, 0.0)
+
+	energy := s.TotalEnergy()
+	for i := 0; i < 200; i++ {
+		s = UpdateState(s)
+	}
+
+	assert.InDelta(t, energy, s.TotalEnergy(), 1e-6)
+	px, py, _ := s.LinearMomentum()
+	assert.InDelta(t, 0.5, px, 1e-12)
+	assert.InDelta(t, 0, py, 1e-12)
+}
@@ -1,15 +1,70 @@
 package dynamics
 
+import (
+	"runtime"
+
+	"github.com/rpagliuca/go-physics/pkg/algebra"
+)
+
 type Acceleration struct {
 	AX float64
 	AY float64
+	AZ float64
 }
 
+// BodyState is three-dimensional, and two-dimensional scenes simply keep Z
+// and VZ at zero
 type BodyState struct {
-	X  float64
-	Y  float64
-	VX float64
-	VY float64
+	X    float64
+	Y    float64
+	Z    float64
+	VX   float64
+	VY   float64
+	VZ   float64
+	Mass float64
+	// Radius defaults to Settings.DefaultRadius when unset
+	Radius float64
+	Charge float64
+	// Restitution defaults to BOUNCING_CONSERVATION when unset, and negative
+	// values such as NO_RESTITUTION mean zero
+	Restitution float64
+}
+
+// GetMass returns the body mass, defaulting to a unit mass when unset
+func (b BodyState) GetMass() float64 {
+	if b.Mass == 0 {
+		return 1
+	}
+	return b.Mass
+}
+
+func (b BodyState) GetRadius(settings Settings) float64 {
+	if b.Radius == 0 {
+		return settings.DefaultRadius
+	}
+	return b.Radius
+}
+
+func (b BodyState) GetRestitution() float64 {
+	return getRestitution(b.Restitution, BOUNCING_CONSERVATION)
+}
+
+// getRestitution resolves a restitution field of a body or a boundary, taking
+// the default when unset
+func getRestitution(restitution, unset float64) float64 {
+	if restitution == 0 {
+		restitution = unset
+	}
+	if restitution < 0 {
+		return 0
+	}
+	return restitution
+}
+
+// GetAccelerationFromForce converts a force acting on the body into the
+// acceleration it causes
+func (b BodyState) GetAccelerationFromForce(fx, fy, fz float64) Acceleration {
+	return Acceleration{fx / b.GetMass(), fy / b.GetMass(), fz / b.GetMass()}
 }
 
 func (b BodyState) Clone() BodyState {
@@ -20,6 +75,18 @@ type State struct {
 	Settings       Settings
 	Bodies         []BodyState
 	GravitySources []GravitySource
+	Forces         []ForceElement
+	Constraints    []Constraint
+	ElectricFields []ElectricField
+	// Uniform magnetic field, out of plane (along Z) in two-dimensional scenes
+	MagneticField algebra.Point3D
+	// Collisions resolved by the last UpdateState
+	Collisions []Collision
+	// Simulated time and number of steps taken by UpdateState
+	Time float64
+	Step int
+	// Events is optional
+	Events *Events
 }
 
 func (s State) Clone() State {
@@ -31,21 +98,108 @@ func (s State) Clone() State {
 	for i := range s.GravitySources {
 		gravitySources = append(gravitySources, s.GravitySources[i].Clone())
 	}
+	forces := []ForceElement{}
+	for i := range s.Forces {
+		forces = append(forces, s.Forces[i].Clone())
+	}
+	constraints := []Constraint{}
+	for i := range s.Constraints {
+		constraints = append(constraints, s.Constraints[i].Clone())
+	}
+	electricFields := []ElectricField{}
+	for i := range s.ElectricFields {
+		electricFields = append(electricFields, s.ElectricFields[i].Clone())
+	}
 	return State{
-		s.Settings.Clone(),
-		bodies,
-		gravitySources,
+		Settings:       s.Settings.Clone(),
+		Bodies:         bodies,
+		GravitySources: gravitySources,
+		Forces:         forces,
+		Constraints:    constraints,
+		ElectricFields: electricFields,
+		MagneticField:  s.MagneticField,
+		Collisions:     append([]Collision{}, s.Collisions...),
+		Time:           s.Time,
+		Step:           s.Step,
+		Events:         s.Events,
 	}
 }
 
 type Settings struct {
-	ViewportWidth       float64
-	ViewportHeight      float64
-	ViewportBoxSize     float64
 	GravityAcceleration float64
 	DeltaTime           float64
+	// Radius of the bodies without their own
+	DefaultRadius float64
+	// Mutual attraction between bodies is only enabled when
+	// GravitationalConstant is not zero
+	GravitationalConstant float64
+	Softening             float64
+	// Electrostatic interaction between charged bodies is only enabled when
+	// CoulombConstant is not zero, sharing the Softening of the attraction
+	CoulombConstant float64
+	// Opening angle of the Barnes-Hut approximation of the mutual attraction,
+	// which is computed exactly when Theta is zero
+	Theta float64
+	// Integrator defaults to FrozenRungeKuttaIntegrator when unset
+	Integrator Integrator
+	// Boundary defaults to UnboundedBoundary when unset. The settings no
+	// longer know the size of the screen, so walls such as the former bounce
+	// at the edges of the viewport must be set as a ReflectiveBoundary.
+	Boundary Boundary
+	// Drag adds an acceleration of -Drag times the velocity to every body
+	Drag              float64
+	CollisionResponse CollisionResponse
+	// BroadPhase defaults to BruteForceBroadPhase when unset
+	BroadPhase BroadPhase
+	// Passes over all constraints after each step, defaulting to
+	// DEFAULT_CONSTRAINT_ITERATIONS when zero
+	ConstraintIterations int
+	// Goroutines evaluating the accelerations of the bodies, defaulting to
+	// GOMAXPROCS when zero. Results do not depend on it.
+	Workers int
 }
 
+// Clone copies the settings, including integrators which keep state between
+// steps, such as DormandPrinceIntegrator
 func (s Settings) Clone() Settings {
+	if integrator, ok := s.Integrator.(*DormandPrinceIntegrator); ok {
+		clone := *integrator
+		s.Integrator = &clone
+	}
 	return s
 }
+
+func (s Settings) GetIntegrator() Integrator {
+	if s.Integrator == nil {
+		return FrozenRungeKuttaIntegrator{}
+	}
+	return s.Integrator
+}
+
+func (s Settings) GetBroadPhase() BroadPhase {
+	if s.BroadPhase == nil {
+		return BruteForceBroadPhase{}
+	}
+	return s.BroadPhase
+}
+
+func (s Settings) GetWorkers() int {
+	if s.Workers == 0 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return s.Workers
+}
+
+func (s Settings) GetConstraintIterations() int {
+	if s.ConstraintIterations == 0 {
+		return DEFAULT_CONSTRAINT_ITERATIONS
+	}
+	return s.ConstraintIterations
+}
+
+func (s Settings) GetBoundary() Boundary {
+	if s.Boundary == nil {
+		return UnboundedBoundary{}
+	}
+	return s.Boundary
+}
@@ -0,0 +1,86 @@
+package dynamics
+
+import (
+	"testing"
+
+	"github.com/rpagliuca/go-physics/pkg/algebra"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyDefaults(t *testing.T) {
+
+	b := BodyState{X: 1, Y: 2}
+
+	assert.Equal(t, 1.0, b.GetMass())
+	assert.Equal(t, 5.0, b.GetRadius(SETTINGS))
+	assert.Equal(t, BOUNCING_CONSERVATION, b.GetRestitution())
+
+	b = BodyState{Mass: 4, Radius: 2, Restitution: 0.8}
+
+	assert.Equal(t, 4.0, b.GetMass())
+	assert.Equal(t, 2.0, b.GetRadius(SETTINGS))
+	assert.Equal(t, 0.8, b.GetRestitution())
+	assert.Equal(t, 0.0, BodyState{Restitution: NO_RESTITUTION}.GetRestitution())
+	assert.Equal(t, Acceleration{0.5, -1, 2}, b.GetAccelerationFromForce(2, -4, 8))
+}
+
+func TestStateCloneKeepsBodyProperties(t *testing.T) {
+
+	s0 := State{
+		Settings: SETTINGS,
+		Bodies: []BodyState{
+			{X: 1, Y: 2, VX: 3, VY: 4, Mass: 5, Radius: 6, Charge: -7, Restitution: 0.5},
+		},
+		GravitySources: []GravitySource{},
+	}
+
+	s1 := s0.Clone()
+	s1.Bodies[0].Mass = 10
+
+	assert.Equal(t, BodyState{X: 1, Y: 2, VX: 3, VY: 4, Mass: 10, Radius: 6, Charge: -7, Restitution: 0.5}, s1.Bodies[0])
+	assert.Equal(t, 5.0, s0.Bodies[0].Mass)
+}
+
+func TestStateCloneCopiesIntegrator(t *testing.T) {
+
+	s := State{
+		Settings: SETTINGS,
+		Bodies:   []BodyState{{X: 500, Y: 500, VX: 3}},
+		GravitySources: []GravitySource{
+			&PointGravitySource{Settings: SETTINGS, Point: algebra.Point3D{X: 510, Y: 500}, GM: 1e4, Softening: 1},
+		},
+	}
+	s.Settings.Integrator = &DormandPrinceIntegrator{}
+
+	first := s.Clone()
+	second := s.Clone()
+	for i := 0; i < 20; i++ {
+		first = UpdateState(first)
+	}
+	second = UpdateState(second)
+
+	// The step size adapted by the first clone is not used by the second
+	expected := UpdateState(s.Clone())
+	assert.Equal(t, expected.Bodies, second.Bodies)
+	assert.Equal(t, &DormandPrinceIntegrator{}, s.Settings.Integrator)
+	assert.NotEqual(t, first.Settings.Integrator, second.Settings.Integrator)
+}
+
+func TestBodyRestitutionOnWalls(t *testing.T) {
+
+	s := State{
+		Settings: SETTINGS,
+		Bodies: []BodyState{
+			{X: 500, Y: 1, VY: -2, Radius: 1, Restitution: 1},
+			{X: 500, Y: 1, VY: -2, Radius: 1},
+		},
+		GravitySources: []GravitySource{},
+	}
+
+	s = UpdateState(s)
+
+	assert.Equal(t, 1.0, s.Bodies[0].Y)
+	assert.Equal(t, 2.0, s.Bodies[0].VY)
+	assert.Equal(t, 2.0*BOUNCING_CONSERVATION, s.Bodies[1].VY)
+}
@@ -0,0 +1,153 @@
+package dynamics
+
+import "math"
+
+// BoundaryHit is a body touching the boundary in a step
+type BoundaryHit struct {
+	Body int
+	// Position of the body after the boundary corrected it
+	X, Y, Z float64
+	// Unit normal pointing from the wall into the box, along the correction
+	// of the position. Zero when the body was removed.
+	NormalX, NormalY, NormalZ float64
+	// Speed of the body towards the wall before the hit
+	Speed   float64
+	Removed bool
+}
+
+// Events lets callers subscribe to UpdateState, shared by the copies of the
+// state. Boundary hits index the bodies before the boundary removed any, and
+// collisions the bodies it left.
+type Events struct {
+	step        []func(state State)
+	boundaryHit []func(hit BoundaryHit)
+	collision   []func(c Collision)
+	bodyAdded   []func(index int, body BodyState)
+	bodyRemoved []func(index int, body BodyState)
+}
+
+// OnStep is called at the end of every UpdateState
+func (e *Events) OnStep(f func(state State)) {
+	e.step = append(e.step, f)
+}
+
+// OnBoundaryHit is called for the bodies corrected or removed by the boundary.
+// Bodies wrapped around by a PeriodicBoundary do not hit it.
+func (e *Events) OnBoundaryHit(f func(hit BoundaryHit)) {
+	e.boundaryHit = append(e.boundaryHit, f)
+}
+
+// OnCollision is called for the collisions resolved between bodies
+func (e *Events) OnCollision(f func(c Collision)) {
+	e.collision = append(e.collision, f)
+}
+
+// OnBodyAdded is called by State.AddBody
+func (e *Events) OnBodyAdded(f func(index int, body BodyState)) {
+	e.bodyAdded = append(e.bodyAdded, f)
+}
+
+// OnBodyRemoved is called for bodies removed by the boundary, merged into
+// other bodies or removed with State.RemoveBody
+func (e *Events) OnBodyRemoved(f func(index int, body BodyState)) {
+	e.bodyRemoved = append(e.bodyRemoved, f)
+}
+
+// The emit methods accept a nil receiver, for states without events
+
+func (e *Events) emitStep(state State) {
+	if e == nil {
+		return
+	}
+	for _, f := range e.step {
+		f(state)
+	}
+}
+
+func (e *Events) emitBoundaryHits(hits []BoundaryHit) {
+	if e == nil {
+		return
+	}
+	for _, hit := range hits {
+		for _, f := range e.boundaryHit {
+			f(hit)
+		}
+	}
+}
+
+func (e *Events) emitCollisions(collisions []Collision) {
+	if e == nil {
+		return
+	}
+	for _, c := range collisions {
+		for _, f := range e.collision {
+			f(c)
+		}
+	}
+}
+
+func (e *Events) emitBodyAdded(index int, body BodyState) {
+	if e == nil {
+		return
+	}
+	for _, f := range e.bodyAdded {
+		f(index, body)
+	}
+}
+
+func (e *Events) emitBodyRemoved(index int, body BodyState) {
+	if e == nil {
+		return
+	}
+	for _, f := range e.bodyRemoved {
+		f(index, body)
+	}
+}
+
+// AddBody appends the body to the state, returning its index
+func (s *State) AddBody(body BodyState) int {
+	// Appending in place could overwrite the bodies added to a copy of the
+	// state sharing the same array
+	s.Bodies = append(append([]BodyState{}, s.Bodies...), body)
+	s.Events.emitBodyAdded(len(s.Bodies)-1, body)
+	return len(s.Bodies) - 1
+}
+
+// RemoveBody removes the body, renumbering the force elements and constraints
+// referencing the bodies after it and dropping the ones referencing it
+func (s *State) RemoveBody(index int) {
+	removed := make([]bool, len(s.Bodies))
+	removed[index] = true
+	// Bodies are filtered in place, which must not affect copies of the state
+	s.Bodies = append([]BodyState{}, s.Bodies...)
+	s.removeBodies(removed)
+}
+
+// getBoundaryHit compares a body before and after the boundary was applied
+func getBoundaryHit(index int, before, after BodyState, kept bool) (BoundaryHit, bool) {
+	if !kept {
+		return BoundaryHit{Body: index, X: before.X, Y: before.Y, Z: before.Z, Removed: true}, true
+	}
+	nx, ny, nz := sign(after.X-before.X), sign(after.Y-before.Y), sign(after.Z-before.Z)
+	length := math.Sqrt(nx*nx + ny*ny + nz*nz)
+	if length == 0 {
+		return BoundaryHit{}, false
+	}
+	nx, ny, nz = nx/length, ny/length, nz/length
+	return BoundaryHit{
+		Body: index,
+		X:    after.X, Y: after.Y, Z: after.Z,
+		NormalX: nx, NormalY: ny, NormalZ: nz,
+		Speed: -(before.VX*nx + before.VY*ny + before.VZ*nz),
+	}, true
+}
+
+func sign(v float64) float64 {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	}
+	return 0
+}
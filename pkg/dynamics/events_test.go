@@ -0,0 +1,187 @@
+package dynamics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoundaryHitEvent(t *testing.T) {
+
+	s0 := State{
+		Settings: SETTINGS,
+		Bodies: []BodyState{
+			{X: 50, Y: 2, VX: 1, VY: -2, Radius: 1},
+			{X: 50, Y: 50, Radius: 1},
+		},
+		Events: &Events{},
+	}
+	s0.Settings.Boundary = ReflectiveBoundary{MaxX: 100, MaxY: 100, Restitution: 1}
+	hits := []BoundaryHit{}
+	s0.Events.OnBoundaryHit(func(hit BoundaryHit) {
+		hits = append(hits, hit)
+	})
+
+	UpdateState(s0)
+
+	assert.Equal(t, []BoundaryHit{{Body: 0, X: 51, Y: 1, NormalY: 1, Speed: 2}}, hits)
+}
+
+func TestAbsorbedBodyEvents(t *testing.T) {
+
+	s0 := State{
+		Settings: SETTINGS,
+		Bodies: []BodyState{
+			{X: 50, Y: 50, Radius: 1},
+			{X: 99, Y: 50, VX: 5, Radius: 1},
+		},
+		Events: &Events{},
+	}
+	s0.Settings.Boundary = AbsorbingBoundary{MaxX: 100, MaxY: 100}
+	hits := []BoundaryHit{}
+	s0.Events.OnBoundaryHit(func(hit BoundaryHit) {
+		hits = append(hits, hit)
+	})
+	removed := []int{}
+	s0.Events.OnBodyRemoved(func(index int, body BodyState) {
+		removed = append(removed, index)
+	})
+
+	s := UpdateState(s0)
+
+	assert.Len(t, s.Bodies, 1)
+	assert.Equal(t, []int{1}, removed)
+	assert.Len(t, hits, 1)
+	assert.True(t, hits[0].Removed)
+	assert.Equal(t, 1, hits[0].Body)
+	assert.Equal(t, 104.0, hits[0].X)
+}
+
+func TestPeriodicWrapIsNotAHit(t *testing.T) {
+
+	s0 := State{
+		Settings: SETTINGS,
+		Bodies:   []BodyState{{X: 99.5, Y: 50, VX: 10, Radius: 1}},
+		Events:   &Events{},
+	}
+	s0.Settings.Boundary = PeriodicBoundary{MaxX: 100, MaxY: 100}
+	hits := []BoundaryHit{}
+	s0.Events.OnBoundaryHit(func(hit BoundaryHit) {
+		hits = append(hits, hit)
+	})
+
+	s := UpdateState(s0)
+
+	assert.Less(t, s.Bodies[0].X, 50.0)
+	assert.Empty(t, hits)
+}
+
+func TestCollisionEventsAfterAbsorption(t *testing.T) {
+
+	s0 := collisionState(ElasticCollisions)
+	// The first body leaves the box, before the other two collide
+	s0.Bodies = append([]BodyState{{X: -1000, Radius: 1}}, s0.Bodies...)
+	s0.Settings.Boundary = AbsorbingBoundary{MinX: -100, MinY: -100, MaxX: 200, MaxY: 200}
+	s0.Events = &Events{}
+	hits := []BoundaryHit{}
+	s0.Events.OnBoundaryHit(func(hit BoundaryHit) {
+		hits = append(hits, hit)
+	})
+	collisions := []Collision{}
+	s0.Events.OnCollision(func(c Collision) {
+		collisions = append(collisions, c)
+	})
+
+	UpdateState(s0)
+
+	assert.Len(t, hits, 1)
+	assert.Equal(t, 0, hits[0].Body)
+	assert.Len(t, collisions, 1)
+	assert.Equal(t, []int{0, 1}, []int{collisions[0].First, collisions[0].Second})
+}
+
+func TestCollisionEvents(t *testing.T) {
+
+	s0 := collisionState(MergingCollisions)
+	s0.Events = &Events{}
+	collisions := []Collision{}
+	s0.Events.OnCollision(func(c Collision) {
+		collisions = append(collisions, c)
+	})
+	removed := []BodyState{}
+	s0.Events.OnBodyRemoved(func(index int, body BodyState) {
+		assert.Equal(t, 1, index)
+		removed = append(removed, body)
+	})
+
+	s := UpdateState(s0)
+
+	assert.Equal(t, s.Collisions, collisions)
+	assert.Len(t, removed, 1)
+	assert.Equal(t, 2.0, removed[0].Mass)
+}
+
+func TestStepEvents(t *testing.T) {
+
+	s := State{Settings: SETTINGS, Bodies: []BodyState{{X: 50, Y: 50}}, Events: &Events{}}
+	steps := []int{}
+	times := []float64{}
+	s.Events.OnStep(func(state State) {
+		steps = append(steps, state.Step)
+		times = append(times, state.Time)
+	})
+
+	for i := 0; i < 3; i++ {
+		s = UpdateState(s)
+	}
+
+	assert.Equal(t, []int{1, 2, 3}, steps)
+	assert.Equal(t, []float64{1, 2, 3}, times)
+}
+
+func TestAddAndRemoveBody(t *testing.T) {
+
+	s := State{
+		Settings: SETTINGS,
+		Bodies:   []BodyState{{X: 1}, {X: 2}},
+		Forces: []ForceElement{
+			Spring{First: 0, Second: 1, Stiffness: 1},
+			LinearDrag{Body: 2, Coefficient: 1},
+		},
+		Constraints: []Constraint{DistanceConstraint{First: 1, Second: 2, Length: 1}},
+		Events:      &Events{},
+	}
+	added := []int{}
+	s.Events.OnBodyAdded(func(index int, body BodyState) {
+		added = append(added, index)
+	})
+	removed := []int{}
+	s.Events.OnBodyRemoved(func(index int, body BodyState) {
+		removed = append(removed, index)
+	})
+
+	assert.Equal(t, 2, s.AddBody(BodyState{X: 3}))
+	assert.Equal(t, []int{2}, added)
+
+	before := s
+	s.RemoveBody(0)
+
+	assert.Equal(t, []int{0}, removed)
+	assert.Equal(t, []BodyState{{X: 2}, {X: 3}}, s.Bodies)
+	assert.Equal(t, []ForceElement{LinearDrag{Body: 1, Coefficient: 1}}, s.Forces)
+	assert.Equal(t, []Constraint{DistanceConstraint{First: 0, Second: 1, Length: 1}}, s.Constraints)
+	// Copies of the state are not affected
+	assert.Equal(t, []BodyState{{X: 1}, {X: 2}, {X: 3}}, before.Bodies)
+}
+
+func TestAddBodyToCopies(t *testing.T) {
+
+	s := State{Settings: SETTINGS, Bodies: make([]BodyState, 1, 4)}
+	other := s
+
+	s.AddBody(BodyState{X: 1})
+	other.AddBody(BodyState{X: 2})
+
+	assert.Equal(t, []BodyState{{}, {X: 1}}, s.Bodies)
+	assert.Equal(t, []BodyState{{}, {X: 2}}, other.Bodies)
+}
@@ -0,0 +1,48 @@
+package dynamics
+
+// FieldGrid is Columns by Rows evenly spaced points at height Z, from
+// (MinX, MinY) to (MaxX, MaxY) included
+type FieldGrid struct {
+	MinX, MinY, MaxX, MaxY float64
+	Z                      float64
+	Columns, Rows          int
+}
+
+// FieldSample is the acceleration and potential energy per unit of mass of
+// the gravity sources at a point
+type FieldSample struct {
+	X, Y, Z      float64
+	Acceleration Acceleration
+	Potential    float64
+}
+
+// SampleField evaluates the gravity sources over the grid, by row and then
+// column. The bodies add no mutual gravity.
+func (s State) SampleField(grid FieldGrid) [][]FieldSample {
+	if grid.Rows <= 0 || grid.Columns <= 0 {
+		return [][]FieldSample{}
+	}
+	samples := make([][]FieldSample, grid.Rows)
+	parallelFor(grid.Rows, s.Settings.GetWorkers(), func(row int) {
+		samples[row] = make([]FieldSample, grid.Columns)
+		y := getGridCoordinate(grid.MinY, grid.MaxY, row, grid.Rows)
+		for column := range samples[row] {
+			// A probe of unit mass
+			probe := BodyState{X: getGridCoordinate(grid.MinX, grid.MaxX, column, grid.Columns), Y: y, Z: grid.Z, Mass: 1}
+			sample := FieldSample{X: probe.X, Y: probe.Y, Z: probe.Z}
+			sample.Acceleration = getAcceleration(probe, s.GravitySources)
+			for _, g := range s.GravitySources {
+				sample.Potential += g.GetPotentialEnergy(probe)
+			}
+			samples[row][column] = sample
+		}
+	})
+	return samples
+}
+
+func getGridCoordinate(min, max float64, i, n int) float64 {
+	if n == 1 {
+		return min
+	}
+	return min + (max-min)*float64(i)/float64(n-1)
+}
@@ -0,0 +1,67 @@
+package dynamics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rpagliuca/go-physics/pkg/algebra"
+	"github.com/stretchr/testify/assert"
+)
+
+func fieldState(workers int) State {
+	settings := SETTINGS
+	settings.Workers = workers
+	return State{
+		Settings: settings,
+		Bodies:   []BodyState{{X: 1, Y: 1, VX: 3, Mass: 100}},
+		GravitySources: []GravitySource{
+			&PointGravitySource{Settings: settings, Point: algebra.Point3D{X: 0, Y: 0}, GM: 8},
+			&BallGravitySource{Settings: settings, Center: algebra.Point{X: 10, Y: 0}, Radius: 2, GM: 4},
+		},
+	}
+}
+
+func TestSampleField(t *testing.T) {
+
+	s := fieldState(1)
+	grid := FieldGrid{MinX: -4, MinY: -2, MaxX: 8, MaxY: 6, Z: 1, Columns: 4, Rows: 3}
+
+	samples := s.SampleField(grid)
+
+	assert.Len(t, samples, 3)
+	for _, row := range samples {
+		assert.Len(t, row, 4)
+	}
+	// Both corners are included
+	assert.Equal(t, []float64{-4, -2, 1}, []float64{samples[0][0].X, samples[0][0].Y, samples[0][0].Z})
+	assert.Equal(t, []float64{8, 6, 1}, []float64{samples[2][3].X, samples[2][3].Y, samples[2][3].Z})
+
+	// The sum of the sources, per unit of mass
+	probe := BodyState{X: 4, Y: 2, Z: 1}
+	sample := samples[1][2]
+	assert.Equal(t, []float64{4, 2}, []float64{sample.X, sample.Y})
+	point, ball := s.GravitySources[0].GetAcceleration(probe), s.GravitySources[1].GetAcceleration(probe)
+	assert.InDelta(t, point.AX+ball.AX, sample.Acceleration.AX, 1e-12)
+	assert.InDelta(t, point.AY+ball.AY, sample.Acceleration.AY, 1e-12)
+	assert.InDelta(t, point.AZ+ball.AZ, sample.Acceleration.AZ, 1e-12)
+	assert.InDelta(t, -8/math.Sqrt(21)-4/math.Sqrt(41), sample.Potential, 1e-12)
+
+	// The bodies stay in place
+	assert.Equal(t, fieldState(1).Bodies, s.Bodies)
+}
+
+func TestSampleFieldWorkers(t *testing.T) {
+
+	grid := FieldGrid{MinX: -20, MinY: -20, MaxX: 20, MaxY: 20, Columns: 41, Rows: 37}
+
+	assert.Equal(t, fieldState(1).SampleField(grid), fieldState(4).SampleField(grid))
+}
+
+func TestSampleFieldSmallGrids(t *testing.T) {
+
+	s := fieldState(0)
+
+	assert.Empty(t, s.SampleField(FieldGrid{MaxX: 1, MaxY: 1, Columns: 0, Rows: 3}))
+	samples := s.SampleField(FieldGrid{MinX: 2, MinY: 3, MaxX: 5, MaxY: 7, Columns: 1, Rows: 1})
+	assert.Equal(t, []float64{2, 3}, []float64{samples[0][0].X, samples[0][0].Y})
+}
@@ -0,0 +1,180 @@
+package dynamics
+
+import (
+	"math"
+
+	"github.com/rpagliuca/go-physics/pkg/algebra"
+)
+
+// ForceElement exerts forces on specific bodies, referenced by their index
+// in State.Bodies
+type ForceElement interface {
+	// AddAccelerations adds the accelerations caused by the element
+	AddAccelerations(bodies []BodyState, accelerations []Acceleration)
+	GetPotentialEnergy(bodies []BodyState) float64
+	// Renumber maps the body indices through indices, -1 meaning removed,
+	// returning false when the element must be removed too
+	Renumber(indices []int) (ForceElement, bool)
+	Clone() ForceElement
+}
+
+// Spring is a Hooke spring connecting two bodies
+type Spring struct {
+	First, Second int
+	Stiffness     float64
+	RestLength    float64
+}
+
+func (s Spring) AddAccelerations(bodies []BodyState, accelerations []Acceleration) {
+	a := bodies[s.First]
+	b := bodies[s.Second]
+	nx, ny, nz, length := getDirection(a.X, a.Y, a.Z, b.X, b.Y, b.Z)
+	force := s.Stiffness * (length - s.RestLength)
+	addForce(bodies, accelerations, s.First, force*nx, force*ny, force*nz)
+	addForce(bodies, accelerations, s.Second, -force*nx, -force*ny, -force*nz)
+}
+
+func (s Spring) GetPotentialEnergy(bodies []BodyState) float64 {
+	a := bodies[s.First]
+	b := bodies[s.Second]
+	_, _, _, length := getDirection(a.X, a.Y, a.Z, b.X, b.Y, b.Z)
+	return s.Stiffness * (length - s.RestLength) * (length - s.RestLength) / 2
+}
+
+func (s Spring) Renumber(indices []int) (ForceElement, bool) {
+	s.First, s.Second = indices[s.First], indices[s.Second]
+	return s, s.First >= 0 && s.Second >= 0
+}
+
+func (s Spring) Clone() ForceElement {
+	return s
+}
+
+// AnchoredSpring is a Hooke spring connecting a body to a fixed point
+type AnchoredSpring struct {
+	Body       int
+	Anchor     algebra.Point3D
+	Stiffness  float64
+	RestLength float64
+}
+
+func (s AnchoredSpring) AddAccelerations(bodies []BodyState, accelerations []Acceleration) {
+	b := bodies[s.Body]
+	nx, ny, nz, length := getDirection(b.X, b.Y, b.Z, s.Anchor.X, s.Anchor.Y, s.Anchor.Z)
+	force := s.Stiffness * (length - s.RestLength)
+	addForce(bodies, accelerations, s.Body, force*nx, force*ny, force*nz)
+}
+
+func (s AnchoredSpring) GetPotentialEnergy(bodies []BodyState) float64 {
+	b := bodies[s.Body]
+	_, _, _, length := getDirection(b.X, b.Y, b.Z, s.Anchor.X, s.Anchor.Y, s.Anchor.Z)
+	return s.Stiffness * (length - s.RestLength) * (length - s.RestLength) / 2
+}
+
+func (s AnchoredSpring) Renumber(indices []int) (ForceElement, bool) {
+	s.Body = indices[s.Body]
+	return s, s.Body >= 0
+}
+
+func (s AnchoredSpring) Clone() ForceElement {
+	return s
+}
+
+// Damper is a dashpot between two bodies, resisting the relative velocity
+// along the line joining them
+type Damper struct {
+	First, Second int
+	Coefficient   float64
+}
+
+func (d Damper) AddAccelerations(bodies []BodyState, accelerations []Acceleration) {
+	a := bodies[d.First]
+	b := bodies[d.Second]
+	nx, ny, nz, _ := getDirection(a.X, a.Y, a.Z, b.X, b.Y, b.Z)
+	// Speed at which the bodies move apart
+	speed := (b.VX-a.VX)*nx + (b.VY-a.VY)*ny + (b.VZ-a.VZ)*nz
+	force := d.Coefficient * speed
+	addForce(bodies, accelerations, d.First, force*nx, force*ny, force*nz)
+	addForce(bodies, accelerations, d.Second, -force*nx, -force*ny, -force*nz)
+}
+
+func (Damper) GetPotentialEnergy([]BodyState) float64 {
+	return 0
+}
+
+func (d Damper) Renumber(indices []int) (ForceElement, bool) {
+	d.First, d.Second = indices[d.First], indices[d.Second]
+	return d, d.First >= 0 && d.Second >= 0
+}
+
+func (d Damper) Clone() ForceElement {
+	return d
+}
+
+// LinearDrag is an air drag force proportional to the body velocity
+type LinearDrag struct {
+	Body        int
+	Coefficient float64
+}
+
+func (d LinearDrag) AddAccelerations(bodies []BodyState, accelerations []Acceleration) {
+	b := bodies[d.Body]
+	addForce(bodies, accelerations, d.Body, -d.Coefficient*b.VX, -d.Coefficient*b.VY, -d.Coefficient*b.VZ)
+}
+
+func (LinearDrag) GetPotentialEnergy([]BodyState) float64 {
+	return 0
+}
+
+func (d LinearDrag) Renumber(indices []int) (ForceElement, bool) {
+	d.Body = indices[d.Body]
+	return d, d.Body >= 0
+}
+
+func (d LinearDrag) Clone() ForceElement {
+	return d
+}
+
+// QuadraticDrag is an air drag force proportional to the square of the body
+// speed
+type QuadraticDrag struct {
+	Body        int
+	Coefficient float64
+}
+
+func (d QuadraticDrag) AddAccelerations(bodies []BodyState, accelerations []Acceleration) {
+	b := bodies[d.Body]
+	factor := -d.Coefficient * math.Sqrt(b.VX*b.VX+b.VY*b.VY+b.VZ*b.VZ)
+	addForce(bodies, accelerations, d.Body, factor*b.VX, factor*b.VY, factor*b.VZ)
+}
+
+func (QuadraticDrag) GetPotentialEnergy([]BodyState) float64 {
+	return 0
+}
+
+func (d QuadraticDrag) Renumber(indices []int) (ForceElement, bool) {
+	d.Body = indices[d.Body]
+	return d, d.Body >= 0
+}
+
+func (d QuadraticDrag) Clone() ForceElement {
+	return d
+}
+
+// getDirection returns the unit vector going from the first point to the
+// second, and the distance between them
+func getDirection(x0, y0, z0, x1, y1, z1 float64) (float64, float64, float64, float64) {
+	dx, dy, dz := x1-x0, y1-y0, z1-z0
+	length := math.Sqrt(dx*dx + dy*dy + dz*dz)
+	if length == 0 {
+		return 0, 0, 0, 0
+	}
+	return dx / length, dy / length, dz / length, length
+}
+
+func addForce(bodies []BodyState, accelerations []Acceleration, i int, fx, fy, fz float64) {
+	acceleration := bodies[i].GetAccelerationFromForce(fx, fy, fz)
+	accelerations[i].AX += acceleration.AX
+	accelerations[i].AY += acceleration.AY
+	accelerations[i].AZ += acceleration.AZ
+}
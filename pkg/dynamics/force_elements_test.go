@@ -0,0 +1,147 @@
+package dynamics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rpagliuca/go-physics/pkg/algebra"
+	"github.com/stretchr/testify/assert"
+)
+
+func springState(forces ...ForceElement) State {
+	settings := SETTINGS
+	settings.Boundary = UnboundedBoundary{}
+	settings.Integrator = RungeKuttaIntegrator{}
+	settings.DeltaTime = 0.01
+	return State{
+		Settings: settings,
+		Bodies: []BodyState{
+			{X: 0, Mass: 1},
+			{X: 12, Mass: 3},
+		},
+		Forces: forces,
+	}
+}
+
+func TestSpringOscillation(t *testing.T) {
+
+	s := springState(Spring{First: 0, Second: 1, Stiffness: 4, RestLength: 10})
+	energy := s.TotalEnergy()
+
+	// Two bodies oscillate with the reduced mass
+	period := 2 * math.Pi / math.Sqrt(4/(1*3.0/4))
+	steps := int(math.Round(period / s.Settings.DeltaTime))
+	for i := 0; i < steps; i++ {
+		s = UpdateState(s)
+	}
+
+	assert.InDelta(t, 12, s.Bodies[1].X-s.Bodies[0].X, 0.01)
+	assert.InDelta(t, energy, s.TotalEnergy(), 1e-6)
+	px, _, _ := s.LinearMomentum()
+	assert.InDelta(t, 0, px, 1e-12)
+}
+
+func TestSpringChain(t *testing.T) {
+
+	s := springState(
+		Spring{First: 0, Second: 1, Stiffness: 4, RestLength: 10},
+		Spring{First: 1, Second: 2, Stiffness: 4, RestLength: 10},
+	)
+	s.Bodies = append(s.Bodies, BodyState{X: 20, Y: 5, Mass: 2})
+	energy := s.TotalEnergy()
+
+	for i := 0; i < 1000; i++ {
+		s = UpdateState(s)
+	}
+
+	assert.InDelta(t, energy, s.TotalEnergy(), 1e-6)
+}
+
+func TestAnchoredSpringEquilibrium(t *testing.T) {
+
+	s := springState(
+		AnchoredSpring{Body: 0, Anchor: algebra.Point3D{X: 0, Y: 0}, Stiffness: 2, RestLength: 10},
+		LinearDrag{Body: 0, Coefficient: 1},
+	)
+	s.Bodies = s.Bodies[:1]
+	s.GravitySources = []GravitySource{
+		&LinearGravitySource{SETTINGS, algebra.Line{X0: -1, Y0: 1000, X1: 1, Y1: 1000}},
+	}
+
+	for i := 0; i < 5000; i++ {
+		s = UpdateState(s)
+	}
+
+	// The spring stretches by m g / k
+	assert.InDelta(t, 10.5, s.Bodies[0].Y, 1e-6)
+}
+
+func TestDamperDissipatesEnergy(t *testing.T) {
+
+	s := springState(
+		Spring{First: 0, Second: 1, Stiffness: 4, RestLength: 10},
+		Damper{First: 0, Second: 1, Coefficient: 0.5},
+	)
+	energy := s.TotalEnergy()
+
+	previous := energy
+	for i := 0; i < 2000; i++ {
+		s = UpdateState(s)
+		assert.LessOrEqual(t, s.TotalEnergy(), previous+1e-12)
+		previous = s.TotalEnergy()
+	}
+
+	assert.Less(t, s.TotalEnergy(), energy/100)
+	assert.InDelta(t, 10, s.Bodies[1].X-s.Bodies[0].X, 0.01)
+}
+
+func TestLinearDrag(t *testing.T) {
+
+	s := springState(LinearDrag{Body: 1, Coefficient: 1.5})
+	s.Bodies[1].VX = 4
+
+	for i := 0; i < 100; i++ {
+		s = UpdateState(s)
+	}
+
+	assert.InDelta(t, 4*math.Exp(-1.5/3), s.Bodies[1].VX, 1e-9)
+	assert.Equal(t, 0.0, s.Bodies[0].VX)
+}
+
+func TestQuadraticDragTerminalSpeed(t *testing.T) {
+
+	s := springState(QuadraticDrag{Body: 0, Coefficient: 0.25})
+	s.Bodies = s.Bodies[:1]
+	s.Bodies[0].VX = 1
+	s.GravitySources = []GravitySource{
+		&LinearGravitySource{SETTINGS, algebra.Line{X0: -1, Y0: 1e9, X1: 1, Y1: 1e9}},
+	}
+
+	for i := 0; i < 5000; i++ {
+		s = UpdateState(s)
+	}
+
+	// Horizontal motion stops and the fall settles at sqrt(m g / c)
+	assert.InDelta(t, 0, s.Bodies[0].VX, 1e-3)
+	assert.InDelta(t, 2, s.Bodies[0].VY, 1e-6)
+}
+
+func TestForcesRenumberedOnRemoval(t *testing.T) {
+
+	s := springState(
+		Spring{First: 0, Second: 1, Stiffness: 1, RestLength: 10},
+		Spring{First: 1, Second: 2, Stiffness: 1, RestLength: 10},
+		LinearDrag{Body: 2, Coefficient: 1},
+	)
+	s.Settings.Boundary = AbsorbingBoundary{MinX: -100, MinY: -100, MaxX: 100, MaxY: 100}
+	s.Bodies[0].X = -1000
+	s.Bodies = append(s.Bodies, BodyState{X: 22})
+
+	s = UpdateState(s)
+
+	assert.Len(t, s.Bodies, 2)
+	assert.Equal(t, []ForceElement{
+		Spring{First: 0, Second: 1, Stiffness: 1, RestLength: 10},
+		LinearDrag{Body: 1, Coefficient: 1},
+	}, s.Forces)
+}
@@ -0,0 +1,659 @@
+package dynamics
+
+import (
+	"math"
+
+	"github.com/rpagliuca/go-physics/pkg/algebra"
+)
+
+// SegmentGravitySource is a finite LinearGravitySource, pulling towards the
+// closest point of the segment
+type SegmentGravitySource struct {
+	Settings Settings
+	Segment  algebra.Line
+}
+
+func (s *SegmentGravitySource) Clone() GravitySource {
+	other := *s
+	other.Settings = s.Settings.Clone()
+	return GravitySource(&other)
+}
+
+func (s SegmentGravitySource) GetPotentialEnergy(b BodyState) float64 {
+	return getOutlinePotentialEnergy(b, s.Segment.ClosestPoint(algebra.Point{X: b.X, Y: b.Y}), s.Settings)
+}
+
+func (s SegmentGravitySource) GetAcceleration(b BodyState) Acceleration {
+	return getOutlineAcceleration(b, s.Segment.ClosestPoint(algebra.Point{X: b.X, Y: b.Y}), s.Settings)
+}
+
+func (s SegmentGravitySource) GetWidth() float64 {
+	return s.Segment.Length()
+}
+
+func (s SegmentGravitySource) GetX() float64 {
+	return s.Segment.X0
+}
+
+func (s SegmentGravitySource) GetY() float64 {
+	return s.Segment.Y0
+}
+
+func (s SegmentGravitySource) GetZ() float64 {
+	return 0
+}
+
+func (s SegmentGravitySource) GetOtherX() float64 {
+	return s.Segment.X1
+}
+
+func (s SegmentGravitySource) GetOtherY() float64 {
+	return s.Segment.Y1
+}
+
+func (s SegmentGravitySource) GetOtherZ() float64 {
+	return 0
+}
+
+// UpdateCenter moves the middle of the segment to the point
+func (s *SegmentGravitySource) UpdateCenter(x, y float64) {
+	dx := x - (s.Segment.X0+s.Segment.X1)/2
+	dy := y - (s.Segment.Y0+s.Segment.Y1)/2
+	s.Segment = algebra.Line{X0: s.Segment.X0 + dx, Y0: s.Segment.Y0 + dy, X1: s.Segment.X1 + dx, Y1: s.Segment.Y1 + dy}
+}
+
+// PolygonGravitySource pulls bodies with Settings.GravityAcceleration towards
+// the closest point of the outline of a polygon
+type PolygonGravitySource struct {
+	Settings Settings
+	Polygon  algebra.Polygon
+}
+
+func (p *PolygonGravitySource) Clone() GravitySource {
+	other := *p
+	other.Settings = p.Settings.Clone()
+	other.Polygon = p.Polygon.Translate(0, 0)
+	return GravitySource(&other)
+}
+
+func (p PolygonGravitySource) GetPotentialEnergy(b BodyState) float64 {
+	return getOutlinePotentialEnergy(b, p.Polygon.ClosestPoint(algebra.Point{X: b.X, Y: b.Y}), p.Settings)
+}
+
+func (p PolygonGravitySource) GetAcceleration(b BodyState) Acceleration {
+	return getOutlineAcceleration(b, p.Polygon.ClosestPoint(algebra.Point{X: b.X, Y: b.Y}), p.Settings)
+}
+
+func (p PolygonGravitySource) GetWidth() float64 {
+	return getPolygonWidth(p.Polygon)
+}
+
+func (p PolygonGravitySource) GetX() float64 {
+	return p.Polygon.Centroid().X
+}
+
+func (p PolygonGravitySource) GetY() float64 {
+	return p.Polygon.Centroid().Y
+}
+
+func (p PolygonGravitySource) GetZ() float64 {
+	return 0
+}
+
+func (p PolygonGravitySource) GetOtherX() float64 {
+	return p.GetX()
+}
+
+func (p PolygonGravitySource) GetOtherY() float64 {
+	return p.GetY()
+}
+
+func (p PolygonGravitySource) GetOtherZ() float64 {
+	return 0
+}
+
+// UpdateCenter moves the centroid of the polygon to the point
+func (p *PolygonGravitySource) UpdateCenter(x, y float64) {
+	c := p.Polygon.Centroid()
+	p.Polygon = p.Polygon.Translate(x-c.X, y-c.Y)
+}
+
+// getOutlinePotentialEnergy of a uniform field pointing towards the closest
+// point of an outline
+func getOutlinePotentialEnergy(b BodyState, closest algebra.Point, settings Settings) float64 {
+	distance := math.Hypot(closest.X-b.X, closest.Y-b.Y)
+	return b.GetMass() * settings.GravityAcceleration * distance
+}
+
+func getOutlineAcceleration(b BodyState, closest algebra.Point, settings Settings) Acceleration {
+	dx, dy := closest.X-b.X, closest.Y-b.Y
+	distance := math.Hypot(dx, dy)
+	if distance == 0 {
+		return Acceleration{0, 0, 0}
+	}
+	g := settings.GravityAcceleration
+	return Acceleration{g * dx / distance, g * dy / distance, 0}
+}
+
+func getPolygonWidth(polygon algebra.Polygon) float64 {
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, v := range polygon.Vertices {
+		min, max = math.Min(min, v.X), math.Max(max, v.X)
+	}
+	return math.Max(0, max-min)
+}
+
+// BallGravitySource is a ball of uniform density, such as a planet, whose
+// pull falls linearly to zero inside it
+type BallGravitySource struct {
+	Settings Settings
+	Center   algebra.Point
+	Z        float64
+	Radius   float64
+	// Strength of the attraction. When GM is not set, Mass is multiplied by
+	// Settings.GravitationalConstant.
+	GM   float64
+	Mass float64
+}
+
+func (ball *BallGravitySource) Clone() GravitySource {
+	other := *ball
+	other.Settings = ball.Settings.Clone()
+	return GravitySource(&other)
+}
+
+func (ball BallGravitySource) GetGM() float64 {
+	if ball.GM != 0 {
+		return ball.GM
+	}
+	return ball.Settings.GravitationalConstant * ball.Mass
+}
+
+func (ball BallGravitySource) GetPotentialEnergy(b BodyState) float64 {
+	dx, dy, dz := ball.Center.X-b.X, ball.Center.Y-b.Y, ball.Z-b.Z
+	distance := math.Sqrt(dx*dx + dy*dy + dz*dz)
+	if distance >= ball.Radius {
+		return -ball.GetGM() * b.GetMass() / distance
+	}
+	return -ball.GetGM() * b.GetMass() * (3*ball.Radius*ball.Radius - distance*distance) / (2 * ball.Radius * ball.Radius * ball.Radius)
+}
+
+func (ball BallGravitySource) GetAcceleration(b BodyState) Acceleration {
+	dx, dy, dz := ball.Center.X-b.X, ball.Center.Y-b.Y, ball.Z-b.Z
+	distance := math.Sqrt(dx*dx + dy*dy + dz*dz)
+	factor := ball.GetGM() / (ball.Radius * ball.Radius * ball.Radius)
+	if distance >= ball.Radius {
+		factor = ball.GetGM() / (distance * distance * distance)
+	}
+	return Acceleration{factor * dx, factor * dy, factor * dz}
+}
+
+func (ball BallGravitySource) GetWidth() float64 {
+	return 2 * ball.Radius
+}
+
+func (ball BallGravitySource) GetX() float64 {
+	return ball.Center.X
+}
+
+func (ball BallGravitySource) GetY() float64 {
+	return ball.Center.Y
+}
+
+func (ball BallGravitySource) GetZ() float64 {
+	return ball.Z
+}
+
+func (ball BallGravitySource) GetOtherX() float64 {
+	return ball.Center.X
+}
+
+func (ball BallGravitySource) GetOtherY() float64 {
+	return ball.Center.Y
+}
+
+func (ball BallGravitySource) GetOtherZ() float64 {
+	return ball.Z
+}
+
+func (ball *BallGravitySource) UpdateCenter(x, y float64) {
+	ball.Center = algebra.Point{X: x, Y: y}
+}
+
+// RingGravitySource is a thin uniform ring around Center at height Z.
+// Softening is the least height used, avoiding the divergence on the ring.
+type RingGravitySource struct {
+	Settings Settings
+	Center   algebra.Point
+	Z        float64
+	Radius   float64
+	// Strength of the attraction. When GM is not set, Mass is multiplied by
+	// Settings.GravitationalConstant.
+	GM        float64
+	Mass      float64
+	Softening float64
+}
+
+func (r *RingGravitySource) Clone() GravitySource {
+	other := *r
+	other.Settings = r.Settings.Clone()
+	return GravitySource(&other)
+}
+
+func (r RingGravitySource) GetGM() float64 {
+	if r.GM != 0 {
+		return r.GM
+	}
+	return r.Settings.GravitationalConstant * r.Mass
+}
+
+// getCylindricalCoordinates returns the distance to the axis and the height,
+// raw and softened
+func getCylindricalCoordinates(center algebra.Point, z, softening float64, b BodyState) (float64, float64, float64) {
+	rho := math.Hypot(b.X-center.X, b.Y-center.Y)
+	h := b.Z - z
+	return rho, h, math.Sqrt(h*h + softening*softening)
+}
+
+func (r RingGravitySource) getCoordinates(b BodyState) (float64, float64, float64) {
+	return getCylindricalCoordinates(r.Center, r.Z, r.Softening, b)
+}
+
+func (r RingGravitySource) GetPotentialEnergy(b BodyState) float64 {
+	rho, _, u := r.getCoordinates(b)
+	q := math.Sqrt((rho+r.Radius)*(rho+r.Radius) + u*u)
+	m := 4 * rho * r.Radius / (q * q)
+	if m >= 1 {
+		return 0
+	}
+	k, _ := getEllipticIntegrals(m, ((r.Radius-rho)*(r.Radius-rho)+u*u)/(q*q))
+	return -2 * r.GetGM() * b.GetMass() * k / (math.Pi * q)
+}
+
+func (r RingGravitySource) GetAcceleration(b BodyState) Acceleration {
+	rho, h, u := r.getCoordinates(b)
+	q := math.Sqrt((rho+r.Radius)*(rho+r.Radius) + u*u)
+	m := 4 * rho * r.Radius / (q * q)
+	if m >= 1 {
+		return Acceleration{0, 0, 0}
+	}
+	// Squared distance to the closest point of the ring
+	near := (r.Radius-rho)*(r.Radius-rho) + u*u
+	k, e := getEllipticIntegrals(m, near/(q*q))
+	az := -2 * r.GetGM() * h * e / (math.Pi * q * near)
+	if rho < 1e-12*r.Radius {
+		return Acceleration{0, 0, az}
+	}
+	// Away from the axis
+	radial := -r.GetGM() / (math.Pi * rho * q) * (k - e*(r.Radius*r.Radius-rho*rho+u*u)/near)
+	return Acceleration{
+		radial * (b.X - r.Center.X) / rho,
+		radial * (b.Y - r.Center.Y) / rho,
+		az,
+	}
+}
+
+// getEllipticIntegrals returns K(m) and E(m), given also mc = 1 - m
+func getEllipticIntegrals(m, mc float64) (float64, float64) {
+	a, b, c := 1.0, math.Sqrt(mc), math.Sqrt(m)
+	sum, power := c*c/2, 0.5
+	for i := 0; i < 64 && math.Abs(c) > 1e-16*a; i++ {
+		a, b, c = (a+b)/2, math.Sqrt(a*b), (a-b)/2
+		power *= 2
+		sum += power * c * c
+	}
+	k := math.Pi / (2 * a)
+	return k, k * (1 - sum)
+}
+
+// getEllipticIntegralThirdKind returns Pi(n, m) for n below 1, given also the
+// complements nc and mc
+func getEllipticIntegralThirdKind(n, nc, mc float64) float64 {
+	k, _ := getEllipticIntegrals(1-mc, mc)
+	return k + n/3*getCarlsonRJ(0, mc, 1, nc)
+}
+
+// getCarlsonRJ is the symmetric elliptic integral RJ(x, y, z, p) for positive
+// p, by the duplication theorem
+func getCarlsonRJ(x, y, z, p float64) float64 {
+	sum, factor := 0.0, 1.0
+	var average, dx, dy, dz, dp float64
+	for i := 0; i < 100; i++ {
+		sx, sy, sz := math.Sqrt(x), math.Sqrt(y), math.Sqrt(z)
+		lambda := sx*(sy+sz) + sy*sz
+		alpha := p*(sx+sy+sz) + sx*sy*sz
+		sum += factor * getCarlsonRC(alpha*alpha, p*(p+lambda)*(p+lambda))
+		factor /= 4
+		x, y, z, p = (x+lambda)/4, (y+lambda)/4, (z+lambda)/4, (p+lambda)/4
+		average = (x + y + z + 2*p) / 5
+		dx, dy, dz, dp = (average-x)/average, (average-y)/average, (average-z)/average, (average-p)/average
+		if math.Max(math.Max(math.Abs(dx), math.Abs(dy)), math.Max(math.Abs(dz), math.Abs(dp))) < 1e-4 {
+			break
+		}
+	}
+	ea := dx*(dy+dz) + dy*dz
+	eb := dx * dy * dz
+	ec := dp * dp
+	ed := ea - 3*ec
+	ee := eb + 2*dp*(ea-ec)
+	series := 1 + ed*(-3.0/14+9.0/88*ed-9.0/52*ee) + eb*(1.0/6+dp*(-3.0/11+dp*3.0/26)) +
+		dp*ea*(1.0/3-dp*3.0/22) - dp*ec/3
+	return 3*sum + factor*series/(average*math.Sqrt(average))
+}
+
+// getCarlsonRC is the degenerate symmetric elliptic integral RC(x, y), for
+// positive y
+func getCarlsonRC(x, y float64) float64 {
+	var average, s float64
+	for i := 0; i < 100; i++ {
+		lambda := 2*math.Sqrt(x)*math.Sqrt(y) + y
+		x, y = (x+lambda)/4, (y+lambda)/4
+		average = (x + 2*y) / 3
+		s = (y - average) / average
+		if math.Abs(s) < 1e-4 {
+			break
+		}
+	}
+	return (1 + s*s*(0.3+s*(1.0/7+s*(0.375+s*9.0/22)))) / math.Sqrt(average)
+}
+
+func (r RingGravitySource) GetWidth() float64 {
+	return 2 * r.Radius
+}
+
+func (r RingGravitySource) GetX() float64 {
+	return r.Center.X
+}
+
+func (r RingGravitySource) GetY() float64 {
+	return r.Center.Y
+}
+
+func (r RingGravitySource) GetZ() float64 {
+	return r.Z
+}
+
+func (r RingGravitySource) GetOtherX() float64 {
+	return r.Center.X
+}
+
+func (r RingGravitySource) GetOtherY() float64 {
+	return r.Center.Y
+}
+
+func (r RingGravitySource) GetOtherZ() float64 {
+	return r.Z
+}
+
+func (r *RingGravitySource) UpdateCenter(x, y float64) {
+	r.Center = algebra.Point{X: x, Y: y}
+}
+
+// DiskGravitySource is a thin uniform disk around Center at height Z, a round
+// FilledPolygonGravitySource
+type DiskGravitySource struct {
+	Settings Settings
+	Center   algebra.Point
+	Z        float64
+	Radius   float64
+	// Strength of the attraction of the whole disk. When GM is not set, Mass
+	// is multiplied by Settings.GravitationalConstant.
+	GM        float64
+	Mass      float64
+	Softening float64
+}
+
+func (d *DiskGravitySource) Clone() GravitySource {
+	other := *d
+	other.Settings = d.Settings.Clone()
+	return GravitySource(&other)
+}
+
+func (d DiskGravitySource) GetGM() float64 {
+	if d.GM != 0 {
+		return d.GM
+	}
+	return d.Settings.GravitationalConstant * d.Mass
+}
+
+// getIntegrals returns K, E, the distance q to the far side of the rim and
+// the solid angle of the disk
+func (d DiskGravitySource) getIntegrals(rho, u float64) (float64, float64, float64, float64, bool) {
+	a := d.Radius
+	q := math.Sqrt((rho+a)*(rho+a) + u*u)
+	m := 4 * rho * a / (q * q)
+	if m >= 1 {
+		return 0, 0, 0, 0, false
+	}
+	// Complements of m and of the characteristic n of Pi, without rounding
+	mc := ((a-rho)*(a-rho) + u*u) / (q * q)
+	nc := (a - rho) * (a - rho) / ((a + rho) * (a + rho))
+	k, e := getEllipticIntegrals(m, mc)
+	t := (a - rho) / (a + rho)
+	var solidAngle float64
+	if nc*q*q >= u*u {
+		n := 4 * rho * a / ((rho + a) * (rho + a))
+		solidAngle = -2 * u / q * (k + t*getEllipticIntegralThirdKind(n, nc, mc))
+		if rho < a {
+			solidAngle += 2 * math.Pi
+		}
+	} else {
+		// Close to the rim, Pi(n, m) diverges, and the relation of Legendre
+		// with Pi(m / n, m) is better conditioned
+		solidAngle = math.Pi - 2*u/q*(k+t*(k-getEllipticIntegralThirdKind(1-u*u/(q*q), u*u/(q*q), mc)))
+	}
+	return k, e, q, solidAngle, true
+}
+
+func (d DiskGravitySource) GetPotentialEnergy(b BodyState) float64 {
+	rho, _, u := getCylindricalCoordinates(d.Center, d.Z, d.Softening, b)
+	k, e, q, solidAngle, ok := d.getIntegrals(rho, u)
+	if !ok {
+		return 0
+	}
+	a := d.Radius
+	// Integral of the inverse distance over the disk
+	integral := 2*q*e + 2*(a*a-rho*rho-u*u)*k/q - u*solidAngle
+	return -d.GetGM() / (math.Pi * a * a) * b.GetMass() * integral
+}
+
+func (d DiskGravitySource) GetAcceleration(b BodyState) Acceleration {
+	rho, h, u := getCylindricalCoordinates(d.Center, d.Z, d.Softening, b)
+	k, e, q, solidAngle, ok := d.getIntegrals(rho, u)
+	if !ok {
+		return Acceleration{0, 0, 0}
+	}
+	a := d.Radius
+	density := d.GetGM() / (math.Pi * a * a)
+	az := 0.0
+	if u != 0 {
+		az = -density * solidAngle * h / u
+	}
+	if rho < 1e-12*a {
+		return Acceleration{0, 0, az}
+	}
+	// Away from the axis
+	radial := -density * 2 / (rho * q) * ((a*a+rho*rho+u*u)*k - q*q*e)
+	return Acceleration{
+		radial * (b.X - d.Center.X) / rho,
+		radial * (b.Y - d.Center.Y) / rho,
+		az,
+	}
+}
+
+func (d DiskGravitySource) GetWidth() float64 {
+	return 2 * d.Radius
+}
+
+func (d DiskGravitySource) GetX() float64 {
+	return d.Center.X
+}
+
+func (d DiskGravitySource) GetY() float64 {
+	return d.Center.Y
+}
+
+func (d DiskGravitySource) GetZ() float64 {
+	return d.Z
+}
+
+func (d DiskGravitySource) GetOtherX() float64 {
+	return d.Center.X
+}
+
+func (d DiskGravitySource) GetOtherY() float64 {
+	return d.Center.Y
+}
+
+func (d DiskGravitySource) GetOtherZ() float64 {
+	return d.Z
+}
+
+func (d *DiskGravitySource) UpdateCenter(x, y float64) {
+	d.Center = algebra.Point{X: x, Y: y}
+}
+
+// FilledPolygonGravitySource is a thin uniform plate filling a polygon at
+// height Z, softened like RingGravitySource
+type FilledPolygonGravitySource struct {
+	Settings Settings
+	Polygon  algebra.Polygon
+	Z        float64
+	// Strength of the attraction of the whole plate. When GM is not set, Mass
+	// is multiplied by Settings.GravitationalConstant.
+	GM        float64
+	Mass      float64
+	Softening float64
+}
+
+func (p *FilledPolygonGravitySource) Clone() GravitySource {
+	other := *p
+	other.Settings = p.Settings.Clone()
+	other.Polygon = p.Polygon.Translate(0, 0)
+	return GravitySource(&other)
+}
+
+func (p FilledPolygonGravitySource) GetGM() float64 {
+	if p.GM != 0 {
+		return p.GM
+	}
+	return p.Settings.GravitationalConstant * p.Mass
+}
+
+// plateEdge is an edge seen from a body: the ends A and B along it, the
+// distance D to the body, positive inside, and the outward normal
+type plateEdge struct {
+	A, B, D          float64
+	NormalX, NormalY float64
+}
+
+// getPlate returns the edges seen from the body, the area density times G
+// and the softened height of the body above the plate
+func (p FilledPolygonGravitySource) getPlate(b BodyState) ([]plateEdge, float64, float64, float64) {
+	area := p.Polygon.SignedArea()
+	if area == 0 {
+		return nil, 0, 0, 0
+	}
+	orientation := math.Copysign(1, area)
+	edges := []plateEdge{}
+	for _, e := range p.Polygon.Edges() {
+		length := e.Length()
+		if length == 0 {
+			continue
+		}
+		ex, ey := (e.X1-e.X0)/length, (e.Y1-e.Y0)/length
+		nx, ny := orientation*ey, -orientation*ex
+		a := (e.X0-b.X)*ex + (e.Y0-b.Y)*ey
+		edges = append(edges, plateEdge{
+			A: a, B: a + length,
+			D:       (e.X0-b.X)*nx + (e.Y0-b.Y)*ny,
+			NormalX: nx, NormalY: ny,
+		})
+	}
+	h := b.Z - p.Z
+	return edges, p.GetGM() / math.Abs(area), h, math.Sqrt(h*h + p.Softening*p.Softening)
+}
+
+// getLogarithm integrates the inverse distance to the body along the edge
+func (e plateEdge) getLogarithm(u float64) float64 {
+	c := math.Sqrt(e.D*e.D + u*u)
+	if c == 0 {
+		if e.A*e.B <= 0 {
+			return 0
+		}
+		return math.Copysign(math.Log(math.Abs(e.B/e.A)), e.B)
+	}
+	return math.Asinh(e.B/c) - math.Asinh(e.A/c)
+}
+
+// getSolidAngle returns the part of the solid angle under which the body
+// sees the plate, that is due to the edge
+func (e plateEdge) getSolidAngle(u float64) float64 {
+	if e.D == 0 {
+		return 0
+	}
+	rA := math.Sqrt(e.A*e.A + e.D*e.D + u*u)
+	rB := math.Sqrt(e.B*e.B + e.D*e.D + u*u)
+	return math.Atan(e.B/e.D) - math.Atan(e.A/e.D) -
+		math.Atan(e.B*u/(e.D*rB)) + math.Atan(e.A*u/(e.D*rA))
+}
+
+func (p FilledPolygonGravitySource) GetPotentialEnergy(b BodyState) float64 {
+	edges, density, _, u := p.getPlate(b)
+	// Integral of the inverse distance over the plate
+	integral := 0.0
+	for _, e := range edges {
+		integral += e.D*e.getLogarithm(u) - u*e.getSolidAngle(u)
+	}
+	return -density * b.GetMass() * integral
+}
+
+func (p FilledPolygonGravitySource) GetAcceleration(b BodyState) Acceleration {
+	edges, density, h, u := p.getPlate(b)
+	acc := Acceleration{}
+	solidAngle := 0.0
+	for _, e := range edges {
+		logarithm := e.getLogarithm(u)
+		acc.AX -= density * e.NormalX * logarithm
+		acc.AY -= density * e.NormalY * logarithm
+		solidAngle += e.getSolidAngle(u)
+	}
+	if u != 0 {
+		acc.AZ = -density * solidAngle * h / u
+	}
+	return acc
+}
+
+func (p FilledPolygonGravitySource) GetWidth() float64 {
+	return getPolygonWidth(p.Polygon)
+}
+
+func (p FilledPolygonGravitySource) GetX() float64 {
+	return p.Polygon.Centroid().X
+}
+
+func (p FilledPolygonGravitySource) GetY() float64 {
+	return p.Polygon.Centroid().Y
+}
+
+func (p FilledPolygonGravitySource) GetZ() float64 {
+	return p.Z
+}
+
+func (p FilledPolygonGravitySource) GetOtherX() float64 {
+	return p.GetX()
+}
+
+func (p FilledPolygonGravitySource) GetOtherY() float64 {
+	return p.GetY()
+}
+
+func (p FilledPolygonGravitySource) GetOtherZ() float64 {
+	return p.Z
+}
+
+// UpdateCenter moves the centroid of the polygon to the point
+func (p *FilledPolygonGravitySource) UpdateCenter(x, y float64) {
+	c := p.Polygon.Centroid()
+	p.Polygon = p.Polygon.Translate(x-c.X, y-c.Y)
+}
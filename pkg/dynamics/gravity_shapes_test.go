@@ -0,0 +1,217 @@
+package dynamics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rpagliuca/go-physics/pkg/algebra"
+	"github.com/stretchr/testify/assert"
+)
+
+// assertGradient checks that the acceleration is minus the gradient of the
+// potential energy, per unit mass, at the body
+func assertGradient(t *testing.T, g GravitySource, b BodyState, delta float64) {
+	const h = 1e-5
+	acc := g.GetAcceleration(b)
+	derivative := func(dx, dy, dz float64) float64 {
+		plus, minus := b, b
+		plus.X, plus.Y, plus.Z = b.X+dx, b.Y+dy, b.Z+dz
+		minus.X, minus.Y, minus.Z = b.X-dx, b.Y-dy, b.Z-dz
+		return -(g.GetPotentialEnergy(plus) - g.GetPotentialEnergy(minus)) / (2 * h) / b.GetMass()
+	}
+	assert.InDelta(t, derivative(h, 0, 0), acc.AX, delta)
+	assert.InDelta(t, derivative(0, h, 0), acc.AY, delta)
+	assert.InDelta(t, derivative(0, 0, h), acc.AZ, delta)
+}
+
+func square(size float64) algebra.Polygon {
+	return algebra.Polygon{Vertices: []algebra.Point{{X: 0, Y: 0}, {X: size, Y: 0}, {X: size, Y: size}, {X: 0, Y: size}}}
+}
+
+func TestSegmentGravitySource(t *testing.T) {
+
+	settings := SETTINGS
+	settings.GravityAcceleration = 2
+	s := SegmentGravitySource{Settings: settings, Segment: algebra.Line{X0: 0, Y0: 0, X1: 10, Y1: 0}}
+
+	// Straight down onto the segment
+	above := BodyState{X: 5, Y: 3, Mass: 3}
+	assert.Equal(t, Acceleration{0, -2, 0}, s.GetAcceleration(above))
+	assert.Equal(t, 18.0, s.GetPotentialEnergy(above))
+
+	// Past the end, towards the end point
+	past := BodyState{X: 13, Y: 4}
+	acc := s.GetAcceleration(past)
+	assert.InDelta(t, -2*3.0/5, acc.AX, 1e-12)
+	assert.InDelta(t, -2*4.0/5, acc.AY, 1e-12)
+	assert.Equal(t, 10.0, s.GetPotentialEnergy(past))
+
+	assert.Equal(t, Acceleration{0, 0, 0}, s.GetAcceleration(BodyState{X: 5}))
+	assertGradient(t, &s, BodyState{X: -2, Y: -1, Mass: 2}, 1e-6)
+
+	s.UpdateCenter(0, 0)
+	assert.Equal(t, algebra.Line{X0: -5, Y0: 0, X1: 5, Y1: 0}, s.Segment)
+	assert.Equal(t, 10.0, s.GetWidth())
+}
+
+func TestPolygonGravitySource(t *testing.T) {
+
+	p := PolygonGravitySource{Settings: SETTINGS, Polygon: square(10)}
+
+	// Towards the closest edge, from inside and from outside
+	assert.Equal(t, Acceleration{-1, 0, 0}, p.GetAcceleration(BodyState{X: 2, Y: 5}))
+	assert.Equal(t, Acceleration{0, -1, 0}, p.GetAcceleration(BodyState{X: 5, Y: 12}))
+	assert.Equal(t, 2.0, p.GetPotentialEnergy(BodyState{X: 5, Y: 12}))
+	assertGradient(t, &p, BodyState{X: 13, Y: 14}, 1e-6)
+
+	// The clone does not share the vertices
+	clone := p.Clone()
+	clone.UpdateCenter(0, 0)
+	assert.Equal(t, 5.0, p.GetX())
+	assert.Equal(t, 0.0, clone.GetX())
+	assert.Equal(t, 10.0, clone.GetWidth())
+}
+
+func TestBallGravitySource(t *testing.T) {
+
+	ball := BallGravitySource{Settings: SETTINGS, Center: algebra.Point{X: 1, Y: 1}, Radius: 2, GM: 8}
+	point := PointGravitySource{Settings: SETTINGS, Point: algebra.Point3D{X: 1, Y: 1}, GM: 8}
+
+	// Outside, like a point
+	outside := BodyState{X: 4, Y: 5, Z: 1}
+	assert.Equal(t, point.GetAcceleration(outside), ball.GetAcceleration(outside))
+	assert.Equal(t, point.GetPotentialEnergy(outside), ball.GetPotentialEnergy(outside))
+
+	// Inside, linear in the distance
+	acc := ball.GetAcceleration(BodyState{X: 2, Y: 1})
+	assert.InDelta(t, -1.0, acc.AX, 1e-12)
+	assert.Equal(t, Acceleration{0, 0, 0}, ball.GetAcceleration(BodyState{X: 1, Y: 1}))
+	assert.Equal(t, -6.0, ball.GetPotentialEnergy(BodyState{X: 1, Y: 1}))
+
+	// Continuous at the surface
+	surface := BodyState{X: 3, Y: 1}
+	assert.InDelta(t, -4.0, ball.GetPotentialEnergy(surface), 1e-12)
+	assertGradient(t, &ball, BodyState{X: 1.5, Y: 0.2, Z: 0.3}, 1e-6)
+	assertGradient(t, &ball, outside, 1e-6)
+}
+
+// circle returns a regular polygon with the vertices on the circle
+func circle(center algebra.Point, radius float64, sides int) algebra.Polygon {
+	polygon := algebra.Polygon{}
+	for i := 0; i < sides; i++ {
+		angle := 2 * math.Pi * float64(i) / float64(sides)
+		polygon.Vertices = append(polygon.Vertices, algebra.Point{X: center.X + radius*math.Cos(angle), Y: center.Y + radius*math.Sin(angle)})
+	}
+	return polygon
+}
+
+func TestDiskGravitySource(t *testing.T) {
+
+	center := algebra.Point{X: 1, Y: 1}
+	d := DiskGravitySource{Settings: SETTINGS, Center: center, Z: 0.5, Radius: 2, GM: 8}
+
+	// On the axis
+	density := 8 / (math.Pi * 4)
+	acc := d.GetAcceleration(BodyState{X: 1, Y: 1, Z: 2})
+	assert.Equal(t, 0.0, acc.AX)
+	assert.InDelta(t, -2*math.Pi*density*(1-1.5/2.5), acc.AZ, 1e-12)
+	assert.InDelta(t, -2*math.Pi*density*(2.5-1.5), d.GetPotentialEnergy(BodyState{X: 1, Y: 1, Z: 2}), 1e-12)
+
+	// Far away, like a point
+	point := PointGravitySource{Settings: SETTINGS, Point: algebra.Point3D{X: center.X, Y: center.Y, Z: 0.5}, GM: 8}
+	far := BodyState{X: 301, Y: 401, Z: 100}
+	assert.InDelta(t, point.GetPotentialEnergy(far), d.GetPotentialEnergy(far), 1e-7)
+	assert.InDelta(t, point.GetAcceleration(far).AX, d.GetAcceleration(far).AX, 1e-9)
+
+	for _, b := range []BodyState{
+		{X: 1.8, Y: 0.4, Z: 0.8}, {X: 2.5, Y: 2, Z: 0.2}, {X: 3, Y: 1, Z: 0.1},
+		{X: 5, Y: 3, Z: -1}, {X: 1.5, Y: 1, Z: 0.5}, {X: 4, Y: 2, Z: 0.5},
+	} {
+		assertGradient(t, &d, b, 1e-6)
+	}
+
+	// The same as a plate with many sides
+	plate := FilledPolygonGravitySource{Settings: SETTINGS, Polygon: circle(center, 2, 4000), Z: 0.5, GM: 8}
+	for _, b := range []BodyState{
+		{X: 1.8, Y: 0.4, Z: 0.8}, {X: 3.5, Y: 1, Z: 0}, {X: 1.5, Y: 1.2, Z: 0.5}, {X: 5, Y: 3, Z: -1},
+	} {
+		expected, acc := plate.GetAcceleration(b), d.GetAcceleration(b)
+		assert.InDelta(t, plate.GetPotentialEnergy(b), d.GetPotentialEnergy(b), 1e-5)
+		assert.InDelta(t, expected.AX, acc.AX, 1e-5)
+		assert.InDelta(t, expected.AY, acc.AY, 1e-5)
+		assert.InDelta(t, expected.AZ, acc.AZ, 1e-5)
+	}
+}
+
+func TestRingGravitySource(t *testing.T) {
+
+	r := RingGravitySource{Settings: SETTINGS, Radius: 1, GM: 3}
+
+	// No pull at the centre
+	assert.Equal(t, Acceleration{0, 0, 0}, r.GetAcceleration(BodyState{}))
+	assert.InDelta(t, -3.0, r.GetPotentialEnergy(BodyState{}), 1e-12)
+
+	// On the axis, every point of the ring is at the same distance
+	acc := r.GetAcceleration(BodyState{Z: 2})
+	assert.InDelta(t, -3*2/math.Pow(5, 1.5), acc.AZ, 1e-12)
+	assert.InDelta(t, -3/math.Sqrt(5), r.GetPotentialEnergy(BodyState{Z: 2}), 1e-12)
+
+	// Far away, like a point
+	far := BodyState{X: 300, Y: 400}
+	assert.InDelta(t, -3.0/500, r.GetPotentialEnergy(far), 1e-7)
+	assert.InDelta(t, -3*300.0/math.Pow(500, 3), r.GetAcceleration(far).AX, 1e-10)
+
+	// Inside the ring, towards the ring
+	assert.Greater(t, r.GetAcceleration(BodyState{X: 0.5}).AX, 0.0)
+
+	assertGradient(t, &r, BodyState{X: 0.7, Y: -0.4, Z: 0.3}, 1e-6)
+	assertGradient(t, &r, BodyState{X: 2, Y: 1, Z: -0.5}, 1e-6)
+
+	// Softening keeps the ring itself finite
+	r.Softening = 0.1
+	acc = r.GetAcceleration(BodyState{X: 1})
+	assert.False(t, math.IsNaN(acc.AX) || math.IsInf(acc.AX, 0))
+}
+
+func TestFilledPolygonGravitySource(t *testing.T) {
+
+	p := FilledPolygonGravitySource{Settings: SETTINGS, Polygon: square(2), GM: 5}
+	point := PointGravitySource{Settings: SETTINGS, Point: algebra.Point3D{X: 1, Y: 1}, GM: 5}
+
+	// Far away, like a point at the centroid
+	far := BodyState{X: 300, Y: 400, Z: 100}
+	assert.InDelta(t, point.GetPotentialEnergy(far), p.GetPotentialEnergy(far), 1e-8)
+	acc, expected := p.GetAcceleration(far), point.GetAcceleration(far)
+	assert.InDelta(t, expected.AX, acc.AX, 1e-9)
+	assert.InDelta(t, expected.AY, acc.AY, 1e-9)
+	assert.InDelta(t, expected.AZ, acc.AZ, 1e-9)
+
+	// No pull along the plate at its centre
+	acc = p.GetAcceleration(BodyState{X: 1, Y: 1, Z: 0.5})
+	assert.InDelta(t, 0.0, acc.AX, 1e-12)
+	assert.InDelta(t, 0.0, acc.AY, 1e-12)
+	assert.Less(t, acc.AZ, 0.0)
+
+	// The orientation of the vertices does not matter
+	reversed := p
+	reversed.Polygon = algebra.Polygon{Vertices: []algebra.Point{{X: 0, Y: 2}, {X: 2, Y: 2}, {X: 2, Y: 0}, {X: 0, Y: 0}}}
+	b := BodyState{X: 3, Y: -1, Z: 0.4}
+	assert.InDelta(t, p.GetPotentialEnergy(b), reversed.GetPotentialEnergy(b), 1e-12)
+	assert.Equal(t, p.GetAcceleration(b), reversed.GetAcceleration(b))
+
+	assertGradient(t, &p, b, 1e-6)
+	assertGradient(t, &p, BodyState{X: 0.5, Y: 1.5, Z: -0.2}, 1e-6)
+	assertGradient(t, &p, BodyState{X: 0.5, Y: 3}, 1e-6)
+
+	// Close above a large plate, the field of an infinite plane, 2 pi G sigma
+	large := FilledPolygonGravitySource{Settings: SETTINGS, Polygon: square(1000), GM: 1e6}
+	acc = large.GetAcceleration(BodyState{X: 500, Y: 500, Z: 1})
+	assert.InDelta(t, -2*math.Pi, acc.AZ, 2e-2)
+	acc = large.GetAcceleration(BodyState{X: 500, Y: 500, Z: -1})
+	assert.InDelta(t, 2*math.Pi, acc.AZ, 2e-2)
+
+	// In the plane, the pull of the plate around the body
+	acc = p.GetAcceleration(BodyState{X: 0.5, Y: 1})
+	assert.Less(t, 0.0, acc.AX)
+	assert.InDelta(t, 0.0, acc.AY, 1e-12)
+}
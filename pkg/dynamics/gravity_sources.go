@@ -1,16 +1,24 @@
 package dynamics
 
-import "github.com/rpagliuca/go-physics/pkg/algebra"
+import (
+	"math"
+
+	"github.com/rpagliuca/go-physics/pkg/algebra"
+)
 
 type GravitySource interface {
 	GetPotentialEnergy(BodyState) float64
+	// GetAcceleration is called concurrently. Forces other than gravity must
+	// be converted with BodyState.GetAccelerationFromForce.
 	GetAcceleration(BodyState) Acceleration
 	GetX() float64
 	GetY() float64
+	GetZ() float64
 	GetOtherX() float64
 	GetOtherY() float64
+	GetOtherZ() float64
 	GetWidth() float64
-	UpdateCenter(x, y int)
+	UpdateCenter(x, y float64)
 	Clone() GravitySource
 }
 
@@ -27,11 +35,13 @@ func (l LinearGravitySource) Clone() GravitySource {
 	return GravitySource(&other)
 }
 
-func (LinearGravitySource) GetPotentialEnergy(BodyState) float64 {
-	return 0
+// GetPotentialEnergy of a uniform field pointing towards the line
+func (l LinearGravitySource) GetPotentialEnergy(b BodyState) float64 {
+	distance := l.Line.Distance(algebra.Point{X: b.X, Y: b.Y})
+	return b.GetMass() * l.Settings.GravityAcceleration * distance
 }
 
-func (*LinearGravitySource) UpdateCenter(x, y int) {
+func (*LinearGravitySource) UpdateCenter(x, y float64) {
 	// Do nothing
 }
 
@@ -47,6 +57,10 @@ func (l LinearGravitySource) GetY() float64 {
 	return l.Line.Y0
 }
 
+func (l LinearGravitySource) GetZ() float64 {
+	return 0
+}
+
 func (l LinearGravitySource) GetOtherX() float64 {
 	return l.Line.X1
 }
@@ -55,65 +69,209 @@ func (l LinearGravitySource) GetOtherY() float64 {
 	return l.Line.Y1
 }
 
+func (l LinearGravitySource) GetOtherZ() float64 {
+	return 0
+}
+
+// GetAcceleration pulls the body towards the line. In three dimensions the
+// line is extended along Z, acting as a vertical plane.
 func (s LinearGravitySource) GetAcceleration(b BodyState) Acceleration {
 	normalized, err := algebra.PerpendicularDecomposition(
 		s.Line, algebra.Point{b.X, b.Y},
 	)
 	if err != nil {
-		return Acceleration{0, 0}
+		return Acceleration{0, 0, 0}
 	}
 	acc := Acceleration{
 		s.Settings.GravityAcceleration * normalized.X1,
 		s.Settings.GravityAcceleration * normalized.Y1,
+		0,
 	}
 	return acc
 }
 
+type PointGravityMode int
+
+const (
+	// Newtonian attraction, decaying with the square of the distance
+	InverseSquareGravity PointGravityMode = iota
+	// Pull of Settings.GravityAcceleration at every distance
+	ConstantMagnitudeGravity
+)
+
 type PointGravitySource struct {
 	Settings Settings
-	Point    algebra.Point
+	Point    algebra.Point3D
+	// Mode is ConstantMagnitudeGravity when neither GM nor Mass is set, as in
+	// the sources written before the inverse-square mode existed
+	Mode PointGravityMode
+	// Strength of the inverse-square attraction. When GM is not set, Mass is
+	// multiplied by Settings.GravitationalConstant.
+	GM   float64
+	Mass float64
+	// Plummer softening length, avoiding the singularity at the point
+	Softening float64
+	// Repulsive sources push bodies away instead
+	Repulsive bool
 }
 
 func (p *PointGravitySource) Clone() GravitySource {
-	other := PointGravitySource{
-		p.Settings.Clone(),
-		p.Point,
-	}
+	other := *p
+	other.Settings = p.Settings.Clone()
 	return GravitySource(&other)
 }
 
+func (p PointGravitySource) getDistance(b BodyState) (float64, float64, float64, float64) {
+	dx := p.Point.X - b.X
+	dy := p.Point.Y - b.Y
+	dz := p.Point.Z - b.Z
+	return dx, dy, dz, math.Sqrt(dx*dx + dy*dy + dz*dz)
+}
+
+func (p PointGravitySource) GetGM() float64 {
+	if p.GM != 0 {
+		return p.GM
+	}
+	return p.Settings.GravitationalConstant * p.Mass
+}
+
+func (p PointGravitySource) getMode() PointGravityMode {
+	if p.GM == 0 && p.Mass == 0 {
+		return ConstantMagnitudeGravity
+	}
+	return p.Mode
+}
+
+func (p PointGravitySource) getSign() float64 {
+	if p.Repulsive {
+		return -1
+	}
+	return 1
+}
+
 func (p PointGravitySource) GetPotentialEnergy(bodyState BodyState) float64 {
-	return 0
+	_, _, _, distance := p.getDistance(bodyState)
+	if p.getMode() == ConstantMagnitudeGravity {
+		return p.getSign() * bodyState.GetMass() * p.Settings.GravityAcceleration * distance
+	}
+	softened := math.Sqrt(distance*distance + p.Softening*p.Softening)
+	if softened == 0 {
+		return 0
+	}
+	return -p.getSign() * p.GetGM() * bodyState.GetMass() / softened
 }
 
 func (p PointGravitySource) GetAcceleration(bodyState BodyState) Acceleration {
-	vector := algebra.Line{bodyState.X, bodyState.Y, p.Point.X, p.Point.Y}
-	normalized := algebra.NormalizeLine(vector)
-	acc := Acceleration{p.Settings.GravityAcceleration * normalized.X1, p.Settings.GravityAcceleration * normalized.Y1}
-	return acc
+	dx, dy, dz, distance := p.getDistance(bodyState)
+	if p.getMode() == ConstantMagnitudeGravity {
+		g := p.getSign() * p.Settings.GravityAcceleration
+		acc := Acceleration{g * dx / distance, g * dy / distance, g * dz / distance}
+		return acc
+	}
+	distance2 := distance*distance + p.Softening*p.Softening
+	if distance2 == 0 {
+		return Acceleration{0, 0, 0}
+	}
+	factor := p.getSign() * p.GetGM() / (distance2 * math.Sqrt(distance2))
+	return Acceleration{factor * dx, factor * dy, factor * dz}
 }
 
+// Points have no width, and are drawn with the size chosen by the viewer
 func (p PointGravitySource) GetWidth() float64 {
-	return p.Settings.ViewportBoxSize
+	return 0
 }
 
 func (p PointGravitySource) GetX() float64 {
-	return p.Point.X - p.Settings.ViewportBoxSize/2
+	return p.Point.X
 }
 
 func (p PointGravitySource) GetY() float64 {
 	return p.Point.Y
 }
 
+func (p PointGravitySource) GetZ() float64 {
+	return p.Point.Z
+}
+
 func (p PointGravitySource) GetOtherX() float64 {
-	return p.Point.X + p.Settings.ViewportBoxSize/2
+	return p.Point.X
 }
 
 func (p PointGravitySource) GetOtherY() float64 {
 	return p.Point.Y
 }
 
-func (p *PointGravitySource) UpdateCenter(x, y int) {
-	p.Point.X = float64(x)
-	p.Point.Y = float64(y)
+func (p PointGravitySource) GetOtherZ() float64 {
+	return p.Point.Z
+}
+
+func (p *PointGravitySource) UpdateCenter(x, y float64) {
+	p.Point.X = x
+	p.Point.Y = y
+}
+
+// PlaneGravitySource is the three-dimensional counterpart of
+// LinearGravitySource, pulling bodies straight towards an infinite plane
+type PlaneGravitySource struct {
+	Settings Settings
+	Plane    algebra.Plane
+}
+
+func (p *PlaneGravitySource) Clone() GravitySource {
+	other := PlaneGravitySource{
+		p.Settings.Clone(),
+		p.Plane,
+	}
+	return GravitySource(&other)
+}
+
+// GetPotentialEnergy of a uniform field pointing towards the plane
+func (p PlaneGravitySource) GetPotentialEnergy(b BodyState) float64 {
+	distance := p.Plane.Distance(algebra.Point3D{X: b.X, Y: b.Y, Z: b.Z})
+	return b.GetMass() * p.Settings.GravityAcceleration * distance
+}
+
+func (p PlaneGravitySource) GetAcceleration(b BodyState) Acceleration {
+	normalized, err := algebra.PlanePerpendicularDecomposition(
+		p.Plane, algebra.Point3D{X: b.X, Y: b.Y, Z: b.Z},
+	)
+	if err != nil {
+		return Acceleration{0, 0, 0}
+	}
+	g := p.Settings.GravityAcceleration
+	return Acceleration{g * normalized.X, g * normalized.Y, g * normalized.Z}
+}
+
+// Planes are infinite, so like points they have no width to scale a drawing
+func (p PlaneGravitySource) GetWidth() float64 {
+	return 0
+}
+
+func (p PlaneGravitySource) GetX() float64 {
+	return p.Plane.Point.X
+}
+
+func (p PlaneGravitySource) GetY() float64 {
+	return p.Plane.Point.Y
+}
+
+func (p PlaneGravitySource) GetZ() float64 {
+	return p.Plane.Point.Z
+}
+
+func (p PlaneGravitySource) GetOtherX() float64 {
+	return p.Plane.Point.X
+}
+
+func (p PlaneGravitySource) GetOtherY() float64 {
+	return p.Plane.Point.Y
+}
+
+func (p PlaneGravitySource) GetOtherZ() float64 {
+	return p.Plane.Point.Z
+}
+
+func (p *PlaneGravitySource) UpdateCenter(x, y float64) {
+	p.Plane.Point.X = x
+	p.Plane.Point.Y = y
 }
@@ -1,10 +1,102 @@
 package dynamics
 
 import (
-	"github.com/stretchr/testify/assert"
+	"math"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func Test(t *testing.T) {
 	assert.True(t, true)
 }
+
+func keplerState(vy float64) State {
+	settings := SETTINGS
+	settings.Boundary = UnboundedBoundary{}
+	settings.Integrator = RungeKuttaIntegrator{}
+	settings.DeltaTime = 2 * math.Pi / 1000
+	return State{
+		Settings: settings,
+		Bodies: []BodyState{
+			{X: 1, VY: vy},
+		},
+		GravitySources: []GravitySource{
+			&PointGravitySource{Settings: settings, GM: 1},
+		},
+	}
+}
+
+func TestInverseSquareCircularOrbit(t *testing.T) {
+
+	s := keplerState(1)
+
+	for i := 0; i < 500; i++ {
+		s = UpdateState(s)
+		assert.InDelta(t, 1.0, math.Hypot(s.Bodies[0].X, s.Bodies[0].Y), 1e-9)
+	}
+	// Half a period later, on the other side
+	assert.InDelta(t, -1.0, s.Bodies[0].X, 1e-9)
+
+	for i := 0; i < 500; i++ {
+		s = UpdateState(s)
+	}
+	assert.InDelta(t, 1.0, s.Bodies[0].X, 1e-9)
+	assert.InDelta(t, 0.0, s.Bodies[0].Y, 1e-9)
+}
+
+func TestEscapeVelocity(t *testing.T) {
+
+	bound := keplerState(1.3)
+	free := keplerState(1.5)
+	assert.Less(t, bound.TotalEnergy(), 0.0)
+	assert.Greater(t, free.TotalEnergy(), 0.0)
+
+	maxBound := 0.0
+	for i := 0; i < 10000; i++ {
+		bound = UpdateState(bound)
+		free = UpdateState(free)
+		maxBound = math.Max(maxBound, math.Hypot(bound.Bodies[0].X, bound.Bodies[0].Y))
+	}
+
+	// Apoapsis of the bound orbit: 2a - 1 with a = 1 / (2 - 1.3^2)
+	assert.InDelta(t, 2/(2-1.69)-1, maxBound, 1e-3)
+	// Leaving at more than the speed at infinity, sqrt(1.5^2 - 2)
+	assert.Greater(t, math.Hypot(free.Bodies[0].X, free.Bodies[0].Y), 0.5*10000*free.Settings.DeltaTime)
+}
+
+func TestPointGravitySourceModes(t *testing.T) {
+
+	settings := SETTINGS
+	settings.GravitationalConstant = 2
+	b := BodyState{X: 3, Y: 4}
+
+	// GM from the mass
+	p := PointGravitySource{Settings: settings, Mass: 50}
+	attracted := p.GetAcceleration(b)
+	assert.InDelta(t, -3*100.0/125, attracted.AX, 1e-12)
+	assert.InDelta(t, -4*100.0/125, attracted.AY, 1e-12)
+	assert.Equal(t, -100.0/5, p.GetPotentialEnergy(b))
+
+	// Repulsion
+	p.Repulsive = true
+	repelled := p.GetAcceleration(b)
+	assert.InDelta(t, 3*100.0/125, repelled.AX, 1e-12)
+	assert.InDelta(t, 4*100.0/125, repelled.AY, 1e-12)
+	assert.Equal(t, 100.0/5, p.GetPotentialEnergy(b))
+
+	// Softening keeps the acceleration finite at the point
+	p = PointGravitySource{Settings: settings, GM: 1, Softening: 0.5}
+	assert.Equal(t, Acceleration{0, 0, 0}, p.GetAcceleration(BodyState{}))
+	assert.Equal(t, -2.0, p.GetPotentialEnergy(BodyState{}))
+
+	// The old constant magnitude pull
+	p = PointGravitySource{Settings: settings, Mode: ConstantMagnitudeGravity}
+	assert.Equal(t, Acceleration{-0.6, -0.8, 0}, p.GetAcceleration(b))
+	assert.Equal(t, Acceleration{-0.6, -0.8, 0}, p.GetAcceleration(BodyState{X: 30, Y: 40}))
+
+	// Sources written before the modes, with neither GM nor mass, keep it
+	legacy := &PointGravitySource{Settings: settings, Point: p.Point}
+	assert.Equal(t, p.GetAcceleration(b), legacy.GetAcceleration(b))
+	assert.Equal(t, p.GetPotentialEnergy(b), legacy.GetPotentialEnergy(b))
+}
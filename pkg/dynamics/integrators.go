@@ -0,0 +1,164 @@
+package dynamics
+
+import "math"
+
+// System evaluates the acceleration of every body for a given configuration
+// of the bodies
+type System interface {
+	GetAccelerations(bodies []BodyState) []Acceleration
+}
+
+// AccelerationFunc allows a plain function to be used as a System
+type AccelerationFunc func(bodies []BodyState) []Acceleration
+
+func (f AccelerationFunc) GetAccelerations(bodies []BodyState) []Acceleration {
+	return f(bodies)
+}
+
+// Integrator advances all the bodies of a system by deltaTime. It must not
+// modify the bodies it receives.
+type Integrator interface {
+	Step(bodies []BodyState, deltaTime float64, system System) []BodyState
+}
+
+// derivative is the time derivative of a body state
+type derivative struct {
+	DX, DY, DZ, DVX, DVY, DVZ float64
+}
+
+func getDerivatives(bodies []BodyState, system System) []derivative {
+	accelerations := system.GetAccelerations(bodies)
+	derivatives := make([]derivative, len(bodies))
+	for i := range bodies {
+		derivatives[i] = derivative{
+			bodies[i].VX, bodies[i].VY, bodies[i].VZ,
+			accelerations[i].AX, accelerations[i].AY, accelerations[i].AZ,
+		}
+	}
+	return derivatives
+}
+
+// advance returns bodies + deltaTime * sum(weights[k] * stages[k])
+func advance(bodies []BodyState, deltaTime float64, weights []float64, stages ...[]derivative) []BodyState {
+	next := make([]BodyState, len(bodies))
+	for i := range bodies {
+		next[i] = bodies[i].Clone()
+		for k := range stages {
+			if weights[k] == 0 {
+				continue
+			}
+			h := deltaTime * weights[k]
+			next[i].X += h * stages[k][i].DX
+			next[i].Y += h * stages[k][i].DY
+			next[i].Z += h * stages[k][i].DZ
+			next[i].VX += h * stages[k][i].DVX
+			next[i].VY += h * stages[k][i].DVY
+			next[i].VZ += h * stages[k][i].DVZ
+		}
+	}
+	return next
+}
+
+// EulerIntegrator is the explicit (forward) Euler method. First order.
+type EulerIntegrator struct{}
+
+func (EulerIntegrator) Step(bodies []BodyState, deltaTime float64, system System) []BodyState {
+	return advance(bodies, deltaTime, []float64{1}, getDerivatives(bodies, system))
+}
+
+// SemiImplicitEulerIntegrator updates the velocity first and then moves the
+// body with the new velocity. First order, but symplectic.
+type SemiImplicitEulerIntegrator struct{}
+
+func (SemiImplicitEulerIntegrator) Step(bodies []BodyState, deltaTime float64, system System) []BodyState {
+	accelerations := system.GetAccelerations(bodies)
+	next := make([]BodyState, len(bodies))
+	for i := range bodies {
+		next[i] = bodies[i].Clone()
+		next[i].VX += accelerations[i].AX * deltaTime
+		next[i].VY += accelerations[i].AY * deltaTime
+		next[i].VZ += accelerations[i].AZ * deltaTime
+		next[i].X += next[i].VX * deltaTime
+		next[i].Y += next[i].VY * deltaTime
+		next[i].Z += next[i].VZ * deltaTime
+	}
+	return next
+}
+
+// VelocityVerletIntegrator is the velocity form of the Störmer-Verlet method.
+// Second order.
+type VelocityVerletIntegrator struct{}
+
+func (VelocityVerletIntegrator) Step(bodies []BodyState, deltaTime float64, system System) []BodyState {
+	accelerations := system.GetAccelerations(bodies)
+	next := make([]BodyState, len(bodies))
+	for i := range bodies {
+		next[i] = bodies[i].Clone()
+		next[i].X += bodies[i].VX*deltaTime + accelerations[i].AX*deltaTime*deltaTime/2
+		next[i].Y += bodies[i].VY*deltaTime + accelerations[i].AY*deltaTime*deltaTime/2
+		next[i].Z += bodies[i].VZ*deltaTime + accelerations[i].AZ*deltaTime*deltaTime/2
+		// Predicted velocity, only relevant for velocity dependent forces
+		next[i].VX += accelerations[i].AX * deltaTime
+		next[i].VY += accelerations[i].AY * deltaTime
+		next[i].VZ += accelerations[i].AZ * deltaTime
+	}
+	nextAccelerations := system.GetAccelerations(next)
+	for i := range next {
+		next[i].VX = bodies[i].VX + (accelerations[i].AX+nextAccelerations[i].AX)*deltaTime/2
+		next[i].VY = bodies[i].VY + (accelerations[i].AY+nextAccelerations[i].AY)*deltaTime/2
+		next[i].VZ = bodies[i].VZ + (accelerations[i].AZ+nextAccelerations[i].AZ)*deltaTime/2
+	}
+	return next
+}
+
+// LeapfrogIntegrator is the drift-kick-drift leapfrog method. Second order.
+type LeapfrogIntegrator struct{}
+
+func (LeapfrogIntegrator) Step(bodies []BodyState, deltaTime float64, system System) []BodyState {
+	next := make([]BodyState, len(bodies))
+	for i := range bodies {
+		next[i] = bodies[i].Clone()
+		next[i].X += bodies[i].VX * deltaTime / 2
+		next[i].Y += bodies[i].VY * deltaTime / 2
+		next[i].Z += bodies[i].VZ * deltaTime / 2
+	}
+	accelerations := system.GetAccelerations(next)
+	for i := range next {
+		next[i].VX += accelerations[i].AX * deltaTime
+		next[i].VY += accelerations[i].AY * deltaTime
+		next[i].VZ += accelerations[i].AZ * deltaTime
+		next[i].X += next[i].VX * deltaTime / 2
+		next[i].Y += next[i].VY * deltaTime / 2
+		next[i].Z += next[i].VZ * deltaTime / 2
+	}
+	return next
+}
+
+// Weights of the three leapfrog steps of YoshidaIntegrator
+var (
+	yoshidaOuter  = 1 / (2 - math.Cbrt(2))
+	yoshidaMiddle = 1 - 2*yoshidaOuter
+)
+
+// YoshidaIntegrator is the fourth order symplectic composition of three
+// leapfrog steps, keeping the energy of orbits bounded
+type YoshidaIntegrator struct{}
+
+func (YoshidaIntegrator) Step(bodies []BodyState, deltaTime float64, system System) []BodyState {
+	leapfrog := LeapfrogIntegrator{}
+	next := leapfrog.Step(bodies, yoshidaOuter*deltaTime, system)
+	next = leapfrog.Step(next, yoshidaMiddle*deltaTime, system)
+	return leapfrog.Step(next, yoshidaOuter*deltaTime, system)
+}
+
+// RungeKuttaIntegrator is the classic fourth order Runge-Kutta method on the
+// coupled positions and velocities
+type RungeKuttaIntegrator struct{}
+
+func (RungeKuttaIntegrator) Step(bodies []BodyState, deltaTime float64, system System) []BodyState {
+	k1 := getDerivatives(bodies, system)
+	k2 := getDerivatives(advance(bodies, deltaTime/2, []float64{1}, k1), system)
+	k3 := getDerivatives(advance(bodies, deltaTime/2, []float64{1}, k2), system)
+	k4 := getDerivatives(advance(bodies, deltaTime, []float64{1}, k3), system)
+	return advance(bodies, deltaTime, []float64{1.0 / 6, 2.0 / 6, 2.0 / 6, 1.0 / 6}, k1, k2, k3, k4)
+}
@@ -0,0 +1,96 @@
+package dynamics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rpagliuca/go-physics/pkg/algebra"
+	"github.com/stretchr/testify/assert"
+)
+
+// Harmonic oscillator with unit angular frequency: x(t) = cos(t)
+var harmonicOscillator = AccelerationFunc(func(bodies []BodyState) []Acceleration {
+	accelerations := make([]Acceleration, len(bodies))
+	for i := range bodies {
+		accelerations[i] = Acceleration{-bodies[i].X, -bodies[i].Y, -bodies[i].Z}
+	}
+	return accelerations
+})
+
+func harmonicOscillatorError(integrator Integrator, deltaTime float64) float64 {
+	const duration = 2.0
+	bodies := []BodyState{{X: 1}}
+	steps := int(math.Round(duration / deltaTime))
+	for i := 0; i < steps; i++ {
+		bodies = integrator.Step(bodies, deltaTime, harmonicOscillator)
+	}
+	return math.Hypot(bodies[0].X-math.Cos(duration), bodies[0].VX+math.Sin(duration))
+}
+
+func TestIntegratorsOrderOfAccuracy(t *testing.T) {
+
+	cases := []struct {
+		name       string
+		integrator Integrator
+		order      float64
+	}{
+		{"euler", EulerIntegrator{}, 1},
+		{"semi-implicit euler", SemiImplicitEulerIntegrator{}, 1},
+		{"velocity verlet", VelocityVerletIntegrator{}, 2},
+		{"leapfrog", LeapfrogIntegrator{}, 2},
+		{"boris", BorisIntegrator{}, 2},
+		{"yoshida", YoshidaIntegrator{}, 4},
+		{"runge-kutta", RungeKuttaIntegrator{}, 4},
+	}
+
+	for _, c := range cases {
+		coarse := harmonicOscillatorError(c.integrator, 0.02)
+		fine := harmonicOscillatorError(c.integrator, 0.01)
+		order := math.Log2(coarse / fine)
+		assert.InDelta(t, c.order, order, 0.15, c.name)
+	}
+}
+
+func TestIntegratorsDoNotModifyInput(t *testing.T) {
+
+	integrators := []Integrator{
+		FrozenRungeKuttaIntegrator{},
+		EulerIntegrator{},
+		SemiImplicitEulerIntegrator{},
+		VelocityVerletIntegrator{},
+		LeapfrogIntegrator{},
+		YoshidaIntegrator{},
+		BorisIntegrator{},
+		RungeKuttaIntegrator{},
+	}
+
+	for _, integrator := range integrators {
+		bodies := []BodyState{{X: 1, VY: 1, Mass: 2}}
+		next := integrator.Step(bodies, 0.1, harmonicOscillator)
+		assert.Equal(t, BodyState{X: 1, VY: 1, Mass: 2}, bodies[0])
+		assert.NotEqual(t, bodies[0], next[0])
+		assert.Equal(t, 2.0, next[0].Mass)
+	}
+}
+
+func TestUpdateStateWithIntegrator(t *testing.T) {
+
+	settings := SETTINGS
+	settings.Integrator = RungeKuttaIntegrator{}
+
+	s0 := State{
+		Settings: settings,
+		Bodies: []BodyState{
+			{X: 5, Y: 10, VX: 1},
+		},
+		GravitySources: []GravitySource{
+			&PointGravitySource{Settings: settings, Point: algebra.Point3D{X: 5, Y: 5}, Mode: ConstantMagnitudeGravity},
+		},
+	}
+
+	s1 := UpdateState(s0.Clone())
+
+	// The pull is re-evaluated as the body moves sideways
+	assert.Less(t, s1.Bodies[0].X, 6.0)
+	assert.Less(t, s1.Bodies[0].VY, 0.0)
+}
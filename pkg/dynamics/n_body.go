@@ -0,0 +1,53 @@
+package dynamics
+
+import "math"
+
+// addMutualGravity adds the attraction between the bodies, summed in the same
+// order for any number of workers
+func addMutualGravity(bodies []BodyState, accelerations []Acceleration, settings Settings) {
+	if settings.GravitationalConstant == 0 {
+		return
+	}
+	if settings.Theta > 0 {
+		addBarnesHutGravity(bodies, accelerations, settings)
+		return
+	}
+	parallelFor(len(bodies), settings.GetWorkers(), func(i int) {
+		for j := range bodies {
+			if j == i {
+				continue
+			}
+			dx := bodies[j].X - bodies[i].X
+			dy := bodies[j].Y - bodies[i].Y
+			dz := bodies[j].Z - bodies[i].Z
+			distance2 := dx*dx + dy*dy + dz*dz + settings.Softening*settings.Softening
+			if distance2 == 0 {
+				continue
+			}
+			factor := settings.GravitationalConstant * bodies[j].GetMass() / (distance2 * math.Sqrt(distance2))
+			accelerations[i].AX += factor * dx
+			accelerations[i].AY += factor * dy
+			accelerations[i].AZ += factor * dz
+		}
+	})
+}
+
+func getMutualPotentialEnergy(bodies []BodyState, settings Settings) float64 {
+	if settings.GravitationalConstant == 0 {
+		return 0
+	}
+	energy := 0.0
+	for i := range bodies {
+		for j := i + 1; j < len(bodies); j++ {
+			dx := bodies[j].X - bodies[i].X
+			dy := bodies[j].Y - bodies[i].Y
+			dz := bodies[j].Z - bodies[i].Z
+			distance := math.Sqrt(dx*dx + dy*dy + dz*dz + settings.Softening*settings.Softening)
+			if distance == 0 {
+				continue
+			}
+			energy -= settings.GravitationalConstant * bodies[i].GetMass() * bodies[j].GetMass() / distance
+		}
+	}
+	return energy
+}
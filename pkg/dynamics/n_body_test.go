@@ -0,0 +1,59 @@
+package dynamics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMutualGravityConservesMomentum(t *testing.T) {
+
+	settings := Settings{
+		DeltaTime:             1,
+		GravitationalConstant: 1,
+	}
+
+	// Circular binary orbit around the centre of mass, at rest in its frame
+	s := State{
+		Settings: settings,
+		Bodies: []BodyState{
+			{X: 5e5 - 25, Y: 5e5, VX: 0, VY: -0.05, Mass: 3},
+			{X: 5e5 + 75, Y: 5e5, VX: 0, VY: 0.15, Mass: 1},
+		},
+		GravitySources: []GravitySource{},
+	}
+
+	for i := 0; i < 1000; i++ {
+		s = UpdateState(s)
+	}
+
+	px := 0.0
+	py := 0.0
+	for _, b := range s.Bodies {
+		px += b.GetMass() * b.VX
+		py += b.GetMass() * b.VY
+	}
+	assert.InDelta(t, 0.0, px, 1e-12)
+	assert.InDelta(t, 0.0, py, 1e-12)
+
+	// The bodies have actually been orbiting each other
+	assert.Greater(t, math.Abs(s.Bodies[1].VX), 0.05)
+}
+
+func TestMutualGravityDisabledByDefault(t *testing.T) {
+
+	s := State{
+		Settings: SETTINGS,
+		Bodies: []BodyState{
+			{X: 100, Y: 100, Mass: 1000},
+			{X: 200, Y: 100, Mass: 1000},
+		},
+		GravitySources: []GravitySource{},
+	}
+
+	s = UpdateState(s)
+
+	assert.Equal(t, 0.0, s.Bodies[0].VX)
+	assert.Equal(t, 0.0, s.Bodies[1].VX)
+}
@@ -0,0 +1,84 @@
+package dynamics
+
+import (
+	"testing"
+
+	"github.com/rpagliuca/go-physics/pkg/algebra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParallelForVisitsEveryIndex(t *testing.T) {
+
+	for _, workers := range []int{0, 1, 3, 8, 200} {
+		visits := make([]int, 100)
+		parallelFor(len(visits), workers, func(i int) {
+			visits[i]++
+		})
+		for i := range visits {
+			assert.Equal(t, 1, visits[i], workers)
+		}
+	}
+}
+
+func TestUpdateStateIndependentOfWorkers(t *testing.T) {
+
+	bodies, _ := randomBodies(300, 1000)
+	for i := range bodies {
+		bodies[i].Charge = float64(i%3 - 1)
+	}
+
+	for _, theta := range []float64{0, 0.5} {
+		settings := SETTINGS
+		settings.Boundary = UnboundedBoundary{}
+		settings.Integrator = RungeKuttaIntegrator{}
+		settings.DeltaTime = 0.1
+		settings.GravitationalConstant = 1
+		settings.CoulombConstant = 0.5
+		settings.Softening = 1
+		settings.Theta = theta
+		s := State{
+			Settings: settings,
+			Bodies:   bodies,
+			GravitySources: []GravitySource{
+				&PointGravitySource{Settings: settings, Point: algebra.Point3D{X: 500, Y: 500}, GM: 100},
+			},
+			ElectricFields: []ElectricField{UniformElectricField{Field: algebra.Point3D{X: 0.1}}},
+		}
+
+		var reference []BodyState
+		for _, workers := range []int{1, 2, 3, 8} {
+			state := s.Clone()
+			state.Settings.Workers = workers
+			for i := 0; i < 5; i++ {
+				state = UpdateState(state)
+			}
+			if reference == nil {
+				reference = state.Bodies
+				continue
+			}
+			assert.Equal(t, reference, state.Bodies, workers)
+		}
+	}
+}
+
+func BenchmarkUpdateStateSerial(b *testing.B) {
+	benchmarkUpdateState(b, 1)
+}
+
+func BenchmarkUpdateStateParallel(b *testing.B) {
+	benchmarkUpdateState(b, 0)
+}
+
+func benchmarkUpdateState(b *testing.B, workers int) {
+	bodies, _ := randomBodies(2000, 1000)
+	settings := SETTINGS
+	settings.Boundary = UnboundedBoundary{}
+	settings.GravitationalConstant = 1
+	settings.Softening = 1
+	settings.Workers = workers
+	s := State{Settings: settings, Bodies: bodies}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		UpdateState(s)
+	}
+}
@@ -0,0 +1,61 @@
+package dynamics
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ReversalReport measures how well a scene retraces its own steps
+type ReversalReport struct {
+	Steps int
+	// Largest distance between the initial and the final position of a body,
+	// and the same for the velocities
+	PositionError float64
+	VelocityError float64
+	// Largest relative energy difference from the start, absolute when the
+	// initial energy is zero
+	MaxEnergyDrift float64
+}
+
+// RunReversal runs the scene forward and then as many steps backward, with a
+// negative DeltaTime. The state is left untouched and its events uncalled.
+func RunReversal(state State, steps int) (ReversalReport, error) {
+
+	if steps < 1 {
+		return ReversalReport{}, errors.New("the number of steps must be positive")
+	}
+	if state.Settings.DeltaTime == 0 {
+		return ReversalReport{}, errors.New("the time step must not be zero")
+	}
+
+	initial := state.Clone()
+	initial.Events = nil
+	initialEnergy := initial.TotalEnergy()
+	report := ReversalReport{Steps: steps}
+
+	s := initial.Clone()
+	for _, deltaTime := range []float64{s.Settings.DeltaTime, -s.Settings.DeltaTime} {
+		s.Settings.DeltaTime = deltaTime
+		for i := 0; i < steps; i++ {
+			s = UpdateState(s)
+			if len(s.Bodies) != len(initial.Bodies) {
+				return report, fmt.Errorf("bodies were removed at step %d", s.Step)
+			}
+			drift := math.Abs(s.TotalEnergy() - initialEnergy)
+			if initialEnergy != 0 {
+				drift /= math.Abs(initialEnergy)
+			}
+			report.MaxEnergyDrift = math.Max(report.MaxEnergyDrift, drift)
+		}
+	}
+
+	for i, b := range s.Bodies {
+		b0 := initial.Bodies[i]
+		position := math.Sqrt((b.X-b0.X)*(b.X-b0.X) + (b.Y-b0.Y)*(b.Y-b0.Y) + (b.Z-b0.Z)*(b.Z-b0.Z))
+		velocity := math.Sqrt((b.VX-b0.VX)*(b.VX-b0.VX) + (b.VY-b0.VY)*(b.VY-b0.VY) + (b.VZ-b0.VZ)*(b.VZ-b0.VZ))
+		report.PositionError = math.Max(report.PositionError, position)
+		report.VelocityError = math.Max(report.VelocityError, velocity)
+	}
+	return report, nil
+}
@@ -0,0 +1,81 @@
+package dynamics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Eccentric orbit with a period of about 15
+func reversalState(integrator Integrator) State {
+	s := keplerState(1.2)
+	s.Settings.Integrator = integrator
+	s.Settings.DeltaTime = 0.02
+	s.Bodies[0].Radius = 0.01
+	return s
+}
+
+func TestYoshidaLongTermOrbit(t *testing.T) {
+
+	// About 20 periods
+	const steps = 15000
+
+	report, err := RunReversal(reversalState(YoshidaIntegrator{}), steps)
+	assert.NoError(t, err)
+	assert.Equal(t, steps, report.Steps)
+	assert.Less(t, report.MaxEnergyDrift, 1e-6)
+	assert.Less(t, report.PositionError, 1e-8)
+	assert.Less(t, report.VelocityError, 1e-8)
+
+	// The default integrator loses energy and spirals inwards
+	report, err = RunReversal(reversalState(nil), steps)
+	assert.NoError(t, err)
+	assert.Greater(t, report.MaxEnergyDrift, 1e-2)
+}
+
+func TestReversalWithDrag(t *testing.T) {
+
+	s := reversalState(YoshidaIntegrator{})
+	s.Settings.Drag = 0.1
+
+	report, err := RunReversal(s, 100)
+	assert.NoError(t, err)
+	assert.Greater(t, report.MaxEnergyDrift, 1e-2)
+	assert.Greater(t, report.PositionError, 1e-4)
+}
+
+func TestReversalErrors(t *testing.T) {
+
+	_, err := RunReversal(reversalState(YoshidaIntegrator{}), 0)
+	assert.EqualError(t, err, "the number of steps must be positive")
+
+	s := reversalState(YoshidaIntegrator{})
+	s.Settings.DeltaTime = 0
+	_, err = RunReversal(s, 10)
+	assert.EqualError(t, err, "the time step must not be zero")
+
+	s = reversalState(YoshidaIntegrator{})
+	s.Settings.Boundary = AbsorbingBoundary{MinX: -2, MinY: -2, MaxX: 2, MaxY: 0.5}
+	_, err = RunReversal(s, 100)
+	assert.EqualError(t, err, "bodies were removed at step 22")
+
+	// The state is left untouched
+	assert.Equal(t, 1.0, s.Bodies[0].X)
+	assert.Equal(t, 0, s.Step)
+}
+
+func TestReversalKeepsAdaptiveIntegrator(t *testing.T) {
+
+	s := reversalState(&DormandPrinceIntegrator{})
+	bodies := append([]BodyState{}, s.Bodies...)
+
+	first, err := RunReversal(s, 100)
+	assert.NoError(t, err)
+	second, err := RunReversal(s, 100)
+	assert.NoError(t, err)
+
+	// The step size adapted during a run is not kept in the state
+	assert.Equal(t, &DormandPrinceIntegrator{}, s.Settings.Integrator)
+	assert.Equal(t, bodies, s.Bodies)
+	assert.Equal(t, first, second)
+}
@@ -1,54 +1,52 @@
 package dynamics
 
-var firstIteration = true
+// FrozenRungeKuttaIntegrator integrates every coordinate and velocity as an
+// independent scalar ODE, keeping the acceleration frozen for the whole step.
+// It is the integrator used when Settings.Integrator is not set.
+type FrozenRungeKuttaIntegrator struct{}
+
+func (FrozenRungeKuttaIntegrator) Step(bodies []BodyState, deltaTime float64, system System) []BodyState {
+	accelerations := system.GetAccelerations(bodies)
+	next := make([]BodyState, len(bodies))
+	for i := range bodies {
+		next[i] = getNextBodyStateRungeKutta(bodies[i], accelerations[i], deltaTime)
+	}
+	return next
+}
 
-func getNextBodyStateRungeKutta(state BodyState, acceleration Acceleration, settings Settings) BodyState {
+func getNextBodyStateRungeKutta(state BodyState, acceleration Acceleration, deltaTime float64) BodyState {
 
-	nextBodyState := BodyState{}
+	nextBodyState := state.Clone()
 
 	dvx := func(t, vx float64) float64 {
 		return acceleration.AX
 	}
-	nextBodyState.VX = rungeKutta(state.VX, settings.DeltaTime, dvx)
+	nextBodyState.VX = rungeKutta(state.VX, deltaTime, dvx)
 
 	dvy := func(t, vy float64) float64 {
 		return acceleration.AY
 	}
-	nextBodyState.VY = rungeKutta(state.VY, settings.DeltaTime, dvy)
+	nextBodyState.VY = rungeKutta(state.VY, deltaTime, dvy)
 
 	dx := func(t, x float64) float64 {
 		return state.VX + acceleration.AX*t
 	}
-	nextBodyState.X = rungeKutta(state.X, settings.DeltaTime, dx)
+	nextBodyState.X = rungeKutta(state.X, deltaTime, dx)
 
 	dy := func(t, y float64) float64 {
 		return state.VY + acceleration.AY*t
 	}
-	nextBodyState.Y = rungeKutta(state.Y, settings.DeltaTime, dy)
+	nextBodyState.Y = rungeKutta(state.Y, deltaTime, dy)
 
-	if nextBodyState.Y > settings.ViewportHeight-settings.ViewportBoxSize {
-		nextBodyState.Y = settings.ViewportHeight - settings.ViewportBoxSize
-		nextBodyState.VY = -BOUNCING_CONSERVATION * state.VY
-		nextBodyState.VX = 0.95 * nextBodyState.VX
-	}
-	if nextBodyState.Y < 0 {
-		nextBodyState.Y = 0
-		nextBodyState.VY = -BOUNCING_CONSERVATION * state.VY
-		nextBodyState.VX = 0.95 * nextBodyState.VX
-	}
-	if nextBodyState.X > settings.ViewportWidth-settings.ViewportBoxSize {
-		nextBodyState.X = settings.ViewportWidth - settings.ViewportBoxSize
-		nextBodyState.VX = -BOUNCING_CONSERVATION * state.VX
-		nextBodyState.VY = 0.95 * nextBodyState.VY
-	}
-	if nextBodyState.X < 0 {
-		nextBodyState.X = 0
-		nextBodyState.VX = -BOUNCING_CONSERVATION * state.VX
-		nextBodyState.VY = 0.95 * nextBodyState.VY
+	dvz := func(t, vz float64) float64 {
+		return acceleration.AZ
 	}
+	nextBodyState.VZ = rungeKutta(state.VZ, deltaTime, dvz)
 
-	nextBodyState.VX = 0.9995 * nextBodyState.VX
-	nextBodyState.VY = 0.9995 * nextBodyState.VY
+	dz := func(t, z float64) float64 {
+		return state.VZ + acceleration.AZ*t
+	}
+	nextBodyState.Z = rungeKutta(state.Z, deltaTime, dz)
 
 	return nextBodyState
 }
@@ -0,0 +1,1227 @@
+package dynamics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/rpagliuca/go-physics/pkg/algebra"
+	"gopkg.in/yaml.v3"
+)
+
+// Scene files describe a State as JSON or YAML. Polymorphic entries, such as
+// gravity sources, integrators and boundaries, are objects told apart by
+// their "type" field. Fields left out take their zero value, so that the
+// usual defaults of Settings and BodyState apply, while a restitution written
+// as zero is NO_RESTITUTION. See cmd/orbit-3d-opengl/scenes for examples.
+
+// SceneError points at the invalid field of a scene. Line is zero for
+// checkpoints.
+type SceneError struct {
+	Line, Column int
+	Field        string
+	Message      string
+}
+
+func (e *SceneError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.Field, e.Message)
+	}
+	return fmt.Sprintf("line %d, column %d: %s: %s", e.Line, e.Column, e.Field, e.Message)
+}
+
+// LoadScene reads a scene in JSON or YAML, which is a superset of JSON
+func LoadScene(r io.Reader) (State, error) {
+	var document yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&document); err != nil {
+		if err == io.EOF {
+			return State{}, fmt.Errorf("empty scene")
+		}
+		return State{}, err
+	}
+	var file sceneFile
+	if err := decodeNode(document.Content[0], "", &file); err != nil {
+		return State{}, err
+	}
+	return file.getState(document.Content[0])
+}
+
+// SaveScene writes the state as a JSON scene. Only the gravity sources, force
+// elements, constraints and fields defined in this package can be saved.
+func SaveScene(w io.Writer, state State) error {
+	file, err := newSceneFile(state)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(file)
+}
+
+// SaveSceneYAML writes the state as a YAML scene, as SaveScene
+func SaveSceneYAML(w io.Writer, state State) error {
+	file, err := newSceneFile(state)
+	if err != nil {
+		return err
+	}
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(file); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+type sceneFile struct {
+	Settings       sceneSettings  `json:"settings" yaml:"settings"`
+	Bodies         []sceneBody    `json:"bodies" yaml:"bodies"`
+	GravitySources []sceneElement `json:"gravitySources,omitempty" yaml:"gravitySources,omitempty"`
+	Forces         []sceneElement `json:"forces,omitempty" yaml:"forces,omitempty"`
+	Constraints    []sceneElement `json:"constraints,omitempty" yaml:"constraints,omitempty"`
+	ElectricFields []sceneElement `json:"electricFields,omitempty" yaml:"electricFields,omitempty"`
+	MagneticField  *sceneVector   `json:"magneticField,omitempty" yaml:"magneticField,omitempty"`
+}
+
+type sceneSettings struct {
+	GravityAcceleration   float64       `json:"gravityAcceleration" yaml:"gravityAcceleration"`
+	DeltaTime             float64       `json:"deltaTime" yaml:"deltaTime"`
+	DefaultRadius         float64       `json:"defaultRadius,omitempty" yaml:"defaultRadius,omitempty"`
+	GravitationalConstant float64       `json:"gravitationalConstant,omitempty" yaml:"gravitationalConstant,omitempty"`
+	Softening             float64       `json:"softening,omitempty" yaml:"softening,omitempty"`
+	CoulombConstant       float64       `json:"coulombConstant,omitempty" yaml:"coulombConstant,omitempty"`
+	Theta                 float64       `json:"theta,omitempty" yaml:"theta,omitempty"`
+	Integrator            *sceneElement `json:"integrator,omitempty" yaml:"integrator,omitempty"`
+	Boundary              *sceneElement `json:"boundary,omitempty" yaml:"boundary,omitempty"`
+	Drag                  float64       `json:"drag,omitempty" yaml:"drag,omitempty"`
+	CollisionResponse     string        `json:"collisionResponse,omitempty" yaml:"collisionResponse,omitempty"`
+	BroadPhase            *sceneElement `json:"broadPhase,omitempty" yaml:"broadPhase,omitempty"`
+	ConstraintIterations  int           `json:"constraintIterations,omitempty" yaml:"constraintIterations,omitempty"`
+	Workers               int           `json:"workers,omitempty" yaml:"workers,omitempty"`
+}
+
+type sceneBody struct {
+	X      float64 `json:"x" yaml:"x"`
+	Y      float64 `json:"y" yaml:"y"`
+	Z      float64 `json:"z,omitempty" yaml:"z,omitempty"`
+	VX     float64 `json:"vx" yaml:"vx"`
+	VY     float64 `json:"vy" yaml:"vy"`
+	VZ     float64 `json:"vz,omitempty" yaml:"vz,omitempty"`
+	Mass   float64 `json:"mass,omitempty" yaml:"mass,omitempty"`
+	Radius float64 `json:"radius,omitempty" yaml:"radius,omitempty"`
+	Charge float64 `json:"charge,omitempty" yaml:"charge,omitempty"`
+	// Restitution is a pointer so that a zero restitution can be written
+	Restitution *float64 `json:"restitution,omitempty" yaml:"restitution,omitempty"`
+}
+
+type sceneVector struct {
+	X float64 `json:"x" yaml:"x"`
+	Y float64 `json:"y" yaml:"y"`
+	Z float64 `json:"z" yaml:"z"`
+}
+
+// sceneElement is a polymorphic entry of a scene, holding the typed value
+// when saving and the YAML node when loading
+type sceneElement struct {
+	value interface{}
+	node  *yaml.Node
+}
+
+func (e sceneElement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.value)
+}
+
+func (e sceneElement) MarshalYAML() (interface{}, error) {
+	return e.value, nil
+}
+
+func (e *sceneElement) UnmarshalYAML(node *yaml.Node) error {
+	e.node = node
+	return nil
+}
+
+// decode returns the typed value of the element, decoding the YAML node when
+// it was loaded from a scene file
+func (e sceneElement) decode(path string, factories map[string]func() interface{}) (interface{}, error) {
+	if e.node != nil {
+		return decodeElement(e.node, path, factories)
+	}
+	name := reflect.Indirect(reflect.ValueOf(e.value)).FieldByName("Type").String()
+	if _, ok := factories[name]; !ok {
+		return nil, nodeError(nil, path+".type", fmt.Sprintf("unknown type %q, must be one of %s", name, getTypeNames(factories)))
+	}
+	return e.value, nil
+}
+
+type sceneType struct {
+	Type string `json:"type" yaml:"type"`
+}
+
+type sceneDormandPrince struct {
+	Type              string  `json:"type" yaml:"type"`
+	AbsoluteTolerance float64 `json:"absoluteTolerance,omitempty" yaml:"absoluteTolerance,omitempty"`
+	RelativeTolerance float64 `json:"relativeTolerance,omitempty" yaml:"relativeTolerance,omitempty"`
+}
+
+type sceneReflectiveBoundary struct {
+	Type string  `json:"type" yaml:"type"`
+	MinX float64 `json:"minX" yaml:"minX"`
+	MinY float64 `json:"minY" yaml:"minY"`
+	MaxX float64 `json:"maxX" yaml:"maxX"`
+	MaxY float64 `json:"maxY" yaml:"maxY"`
+	MinZ float64 `json:"minZ,omitempty" yaml:"minZ,omitempty"`
+	MaxZ float64 `json:"maxZ,omitempty" yaml:"maxZ,omitempty"`
+	// Restitution is a pointer so that a zero restitution can be written,
+	// as for bodies
+	Restitution *float64 `json:"restitution,omitempty" yaml:"restitution,omitempty"`
+	Friction    float64  `json:"friction" yaml:"friction"`
+}
+
+// sceneBox is a periodic or absorbing boundary
+type sceneBox struct {
+	Type string  `json:"type" yaml:"type"`
+	MinX float64 `json:"minX" yaml:"minX"`
+	MinY float64 `json:"minY" yaml:"minY"`
+	MaxX float64 `json:"maxX" yaml:"maxX"`
+	MaxY float64 `json:"maxY" yaml:"maxY"`
+	MinZ float64 `json:"minZ,omitempty" yaml:"minZ,omitempty"`
+	MaxZ float64 `json:"maxZ,omitempty" yaml:"maxZ,omitempty"`
+}
+
+type sceneSpatialHash struct {
+	Type     string  `json:"type" yaml:"type"`
+	CellSize float64 `json:"cellSize" yaml:"cellSize"`
+}
+
+type sceneLinearSource struct {
+	Type string  `json:"type" yaml:"type"`
+	X0   float64 `json:"x0" yaml:"x0"`
+	Y0   float64 `json:"y0" yaml:"y0"`
+	X1   float64 `json:"x1" yaml:"x1"`
+	Y1   float64 `json:"y1" yaml:"y1"`
+}
+
+type scenePointSource struct {
+	Type      string  `json:"type" yaml:"type"`
+	X         float64 `json:"x" yaml:"x"`
+	Y         float64 `json:"y" yaml:"y"`
+	Z         float64 `json:"z,omitempty" yaml:"z,omitempty"`
+	Mode      string  `json:"mode,omitempty" yaml:"mode,omitempty"`
+	GM        float64 `json:"gm,omitempty" yaml:"gm,omitempty"`
+	Mass      float64 `json:"mass,omitempty" yaml:"mass,omitempty"`
+	Softening float64 `json:"softening,omitempty" yaml:"softening,omitempty"`
+	Repulsive bool    `json:"repulsive,omitempty" yaml:"repulsive,omitempty"`
+}
+
+type scenePlaneSource struct {
+	Type   string      `json:"type" yaml:"type"`
+	Point  sceneVector `json:"point" yaml:"point"`
+	Normal sceneVector `json:"normal" yaml:"normal"`
+}
+
+type scenePoint struct {
+	X float64 `json:"x" yaml:"x"`
+	Y float64 `json:"y" yaml:"y"`
+}
+
+type scenePolygonSource struct {
+	Type     string       `json:"type" yaml:"type"`
+	Vertices []scenePoint `json:"vertices" yaml:"vertices"`
+}
+
+type sceneFilledPolygonSource struct {
+	Type      string       `json:"type" yaml:"type"`
+	Vertices  []scenePoint `json:"vertices" yaml:"vertices"`
+	Z         float64      `json:"z,omitempty" yaml:"z,omitempty"`
+	GM        float64      `json:"gm,omitempty" yaml:"gm,omitempty"`
+	Mass      float64      `json:"mass,omitempty" yaml:"mass,omitempty"`
+	Softening float64      `json:"softening,omitempty" yaml:"softening,omitempty"`
+}
+
+type sceneBallSource struct {
+	Type   string  `json:"type" yaml:"type"`
+	X      float64 `json:"x" yaml:"x"`
+	Y      float64 `json:"y" yaml:"y"`
+	Z      float64 `json:"z,omitempty" yaml:"z,omitempty"`
+	Radius float64 `json:"radius" yaml:"radius"`
+	GM     float64 `json:"gm,omitempty" yaml:"gm,omitempty"`
+	Mass   float64 `json:"mass,omitempty" yaml:"mass,omitempty"`
+}
+
+type sceneCircleSource struct {
+	Type      string  `json:"type" yaml:"type"`
+	X         float64 `json:"x" yaml:"x"`
+	Y         float64 `json:"y" yaml:"y"`
+	Z         float64 `json:"z,omitempty" yaml:"z,omitempty"`
+	Radius    float64 `json:"radius" yaml:"radius"`
+	GM        float64 `json:"gm,omitempty" yaml:"gm,omitempty"`
+	Mass      float64 `json:"mass,omitempty" yaml:"mass,omitempty"`
+	Softening float64 `json:"softening,omitempty" yaml:"softening,omitempty"`
+}
+
+type sceneSpring struct {
+	Type       string  `json:"type" yaml:"type"`
+	First      int     `json:"first" yaml:"first"`
+	Second     int     `json:"second" yaml:"second"`
+	Stiffness  float64 `json:"stiffness" yaml:"stiffness"`
+	RestLength float64 `json:"restLength" yaml:"restLength"`
+}
+
+type sceneAnchoredSpring struct {
+	Type       string      `json:"type" yaml:"type"`
+	Body       int         `json:"body" yaml:"body"`
+	Anchor     sceneVector `json:"anchor" yaml:"anchor"`
+	Stiffness  float64     `json:"stiffness" yaml:"stiffness"`
+	RestLength float64     `json:"restLength" yaml:"restLength"`
+}
+
+type sceneDamper struct {
+	Type        string  `json:"type" yaml:"type"`
+	First       int     `json:"first" yaml:"first"`
+	Second      int     `json:"second" yaml:"second"`
+	Coefficient float64 `json:"coefficient" yaml:"coefficient"`
+}
+
+// sceneDrag is a linear or quadratic drag
+type sceneDrag struct {
+	Type        string  `json:"type" yaml:"type"`
+	Body        int     `json:"body" yaml:"body"`
+	Coefficient float64 `json:"coefficient" yaml:"coefficient"`
+}
+
+// sceneDistance is a distance or max distance constraint
+type sceneDistance struct {
+	Type   string  `json:"type" yaml:"type"`
+	First  int     `json:"first" yaml:"first"`
+	Second int     `json:"second" yaml:"second"`
+	Length float64 `json:"length" yaml:"length"`
+}
+
+type sceneFixedPoint struct {
+	Type  string      `json:"type" yaml:"type"`
+	Body  int         `json:"body" yaml:"body"`
+	Point sceneVector `json:"point" yaml:"point"`
+}
+
+type sceneUniformElectricField struct {
+	Type  string      `json:"type" yaml:"type"`
+	Field sceneVector `json:"field" yaml:"field"`
+}
+
+var collisionResponseNames = map[CollisionResponse]string{
+	NoCollisions:        "none",
+	ElasticCollisions:   "elastic",
+	InelasticCollisions: "inelastic",
+	MergingCollisions:   "merging",
+}
+
+var pointGravityModeNames = map[PointGravityMode]string{
+	InverseSquareGravity:     "inverse-square",
+	ConstantMagnitudeGravity: "constant-magnitude",
+}
+
+// Factories of the polymorphic entries by their type
+var (
+	sceneIntegrators = map[string]func() interface{}{
+		"frozen-runge-kutta":  func() interface{} { return &sceneType{} },
+		"euler":               func() interface{} { return &sceneType{} },
+		"semi-implicit-euler": func() interface{} { return &sceneType{} },
+		"velocity-verlet":     func() interface{} { return &sceneType{} },
+		"leapfrog":            func() interface{} { return &sceneType{} },
+		"yoshida":             func() interface{} { return &sceneType{} },
+		"boris":               func() interface{} { return &sceneType{} },
+		"runge-kutta":         func() interface{} { return &sceneType{} },
+		"dormand-prince":      func() interface{} { return &sceneDormandPrince{} },
+	}
+	sceneBoundaries = map[string]func() interface{}{
+		"reflective": func() interface{} { return &sceneReflectiveBoundary{} },
+		"periodic":   func() interface{} { return &sceneBox{} },
+		"absorbing":  func() interface{} { return &sceneBox{} },
+		"unbounded":  func() interface{} { return &sceneType{} },
+	}
+	sceneBroadPhases = map[string]func() interface{}{
+		"brute-force":     func() interface{} { return &sceneType{} },
+		"spatial-hash":    func() interface{} { return &sceneSpatialHash{} },
+		"sweep-and-prune": func() interface{} { return &sceneType{} },
+	}
+	sceneGravitySources = map[string]func() interface{}{
+		"linear":         func() interface{} { return &sceneLinearSource{} },
+		"segment":        func() interface{} { return &sceneLinearSource{} },
+		"point":          func() interface{} { return &scenePointSource{} },
+		"plane":          func() interface{} { return &scenePlaneSource{} },
+		"polygon":        func() interface{} { return &scenePolygonSource{} },
+		"filled-polygon": func() interface{} { return &sceneFilledPolygonSource{} },
+		"ball":           func() interface{} { return &sceneBallSource{} },
+		"ring":           func() interface{} { return &sceneCircleSource{} },
+		"disk":           func() interface{} { return &sceneCircleSource{} },
+	}
+	sceneForces = map[string]func() interface{}{
+		"spring":          func() interface{} { return &sceneSpring{} },
+		"anchored-spring": func() interface{} { return &sceneAnchoredSpring{} },
+		"damper":          func() interface{} { return &sceneDamper{} },
+		"linear-drag":     func() interface{} { return &sceneDrag{} },
+		"quadratic-drag":  func() interface{} { return &sceneDrag{} },
+	}
+	sceneConstraints = map[string]func() interface{}{
+		"distance":     func() interface{} { return &sceneDistance{} },
+		"max-distance": func() interface{} { return &sceneDistance{} },
+		"fixed-point":  func() interface{} { return &sceneFixedPoint{} },
+	}
+	sceneElectricFields = map[string]func() interface{}{
+		"uniform": func() interface{} { return &sceneUniformElectricField{} },
+	}
+)
+
+func (v sceneVector) toPoint3D() algebra.Point3D {
+	return algebra.Point3D{X: v.X, Y: v.Y, Z: v.Z}
+}
+
+func newSceneVector(p algebra.Point3D) sceneVector {
+	return sceneVector{X: p.X, Y: p.Y, Z: p.Z}
+}
+
+// getState validates the decoded file and builds the state it describes
+func (f sceneFile) getState(root *yaml.Node) (State, error) {
+
+	settingsNode := getField(root, "settings")
+	settings, err := f.Settings.getSettings(settingsNode)
+	if err != nil {
+		return State{}, err
+	}
+	state := State{Settings: settings}
+
+	bodiesNode := getField(root, "bodies")
+	for i, b := range f.Bodies {
+		var node *yaml.Node
+		if bodiesNode != nil {
+			node = bodiesNode.Content[i]
+		}
+		path := fmt.Sprintf("bodies[%d]", i)
+		for _, field := range []struct {
+			name  string
+			value float64
+		}{{"mass", b.Mass}, {"radius", b.Radius}} {
+			if field.value < 0 {
+				return State{}, fieldError(node, path, field.name, "must not be negative")
+			}
+		}
+		restitution, err := getSceneRestitution(node, path, b.Restitution)
+		if err != nil {
+			return State{}, err
+		}
+		state.Bodies = append(state.Bodies, BodyState{
+			X: b.X, Y: b.Y, Z: b.Z,
+			VX: b.VX, VY: b.VY, VZ: b.VZ,
+			Mass: b.Mass, Radius: b.Radius, Charge: b.Charge, Restitution: restitution,
+		})
+	}
+
+	for i, e := range f.GravitySources {
+		path := fmt.Sprintf("gravitySources[%d]", i)
+		source, err := getGravitySource(e, path, settings)
+		if err != nil {
+			return State{}, err
+		}
+		state.GravitySources = append(state.GravitySources, source)
+	}
+
+	for i, e := range f.Forces {
+		path := fmt.Sprintf("forces[%d]", i)
+		force, err := getForceElement(e, path, len(state.Bodies))
+		if err != nil {
+			return State{}, err
+		}
+		state.Forces = append(state.Forces, force)
+	}
+
+	for i, e := range f.Constraints {
+		path := fmt.Sprintf("constraints[%d]", i)
+		constraint, err := getConstraint(e, path, len(state.Bodies))
+		if err != nil {
+			return State{}, err
+		}
+		state.Constraints = append(state.Constraints, constraint)
+	}
+
+	for i, e := range f.ElectricFields {
+		path := fmt.Sprintf("electricFields[%d]", i)
+		value, err := e.decode(path, sceneElectricFields)
+		if err != nil {
+			return State{}, err
+		}
+		field := value.(*sceneUniformElectricField)
+		state.ElectricFields = append(state.ElectricFields, UniformElectricField{Field: field.Field.toPoint3D()})
+	}
+
+	if f.MagneticField != nil {
+		state.MagneticField = f.MagneticField.toPoint3D()
+	}
+
+	return state, nil
+}
+
+// getSceneRestitution converts a restitution of a scene, where a written zero
+// is NO_RESTITUTION
+func getSceneRestitution(node *yaml.Node, path string, restitution *float64) (float64, error) {
+	if restitution == nil {
+		return 0, nil
+	}
+	if *restitution < 0 {
+		return 0, fieldError(node, path, "restitution", "must not be negative")
+	}
+	if *restitution == 0 {
+		return NO_RESTITUTION, nil
+	}
+	return *restitution, nil
+}
+
+// newSceneRestitution is the inverse of getSceneRestitution
+func newSceneRestitution(restitution float64) *float64 {
+	if restitution == 0 {
+		return nil
+	}
+	r := getRestitution(restitution, 0)
+	return &r
+}
+
+func (s sceneSettings) getSettings(node *yaml.Node) (Settings, error) {
+
+	path := "settings"
+	if s.DeltaTime <= 0 {
+		return Settings{}, fieldError(node, path, "deltaTime", "must be positive")
+	}
+	for _, field := range []struct {
+		name  string
+		value float64
+	}{
+		{"defaultRadius", s.DefaultRadius},
+		{"softening", s.Softening},
+		{"theta", s.Theta},
+		{"drag", s.Drag},
+		{"constraintIterations", float64(s.ConstraintIterations)},
+		{"workers", float64(s.Workers)},
+	} {
+		if field.value < 0 {
+			return Settings{}, fieldError(node, path, field.name, "must not be negative")
+		}
+	}
+
+	settings := Settings{
+		GravityAcceleration:   s.GravityAcceleration,
+		DeltaTime:             s.DeltaTime,
+		DefaultRadius:         s.DefaultRadius,
+		GravitationalConstant: s.GravitationalConstant,
+		Softening:             s.Softening,
+		CoulombConstant:       s.CoulombConstant,
+		Theta:                 s.Theta,
+		Drag:                  s.Drag,
+		ConstraintIterations:  s.ConstraintIterations,
+		Workers:               s.Workers,
+	}
+
+	if s.CollisionResponse != "" {
+		found := false
+		for response, name := range collisionResponseNames {
+			if name == s.CollisionResponse {
+				settings.CollisionResponse = response
+				found = true
+			}
+		}
+		if !found {
+			return Settings{}, fieldError(node, path, "collisionResponse", "must be one of "+getNames(collisionResponseNames))
+		}
+	}
+
+	if s.Integrator != nil {
+		integrator, err := getIntegrator(*s.Integrator, path+".integrator")
+		if err != nil {
+			return Settings{}, err
+		}
+		settings.Integrator = integrator
+	}
+
+	if s.Boundary != nil {
+		boundary, err := getBoundary(*s.Boundary, path+".boundary")
+		if err != nil {
+			return Settings{}, err
+		}
+		settings.Boundary = boundary
+	}
+
+	if s.BroadPhase != nil {
+		value, err := s.BroadPhase.decode(path+".broadPhase", sceneBroadPhases)
+		if err != nil {
+			return Settings{}, err
+		}
+		switch v := value.(type) {
+		case *sceneSpatialHash:
+			if v.CellSize <= 0 {
+				return Settings{}, fieldError(s.BroadPhase.node, path+".broadPhase", "cellSize", "must be positive")
+			}
+			settings.BroadPhase = SpatialHashBroadPhase{CellSize: v.CellSize}
+		case *sceneType:
+			if v.Type == "sweep-and-prune" {
+				settings.BroadPhase = SweepAndPruneBroadPhase{}
+			} else {
+				settings.BroadPhase = BruteForceBroadPhase{}
+			}
+		}
+	}
+
+	return settings, nil
+}
+
+// NewIntegrator returns the integrator with the given name in scene files,
+// such as "runge-kutta", with default parameters
+func NewIntegrator(name string) (Integrator, error) {
+	factory, ok := sceneIntegrators[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown integrator %q, must be one of %s", name, getTypeNames(sceneIntegrators))
+	}
+	value := factory()
+	reflect.ValueOf(value).Elem().FieldByName("Type").SetString(name)
+	return getIntegrator(sceneElement{value: value}, "integrator")
+}
+
+func getIntegrator(e sceneElement, path string) (Integrator, error) {
+	node := e.node
+	value, err := e.decode(path, sceneIntegrators)
+	if err != nil {
+		return nil, err
+	}
+	switch v := value.(type) {
+	case *sceneDormandPrince:
+		if v.AbsoluteTolerance < 0 {
+			return nil, fieldError(node, path, "absoluteTolerance", "must not be negative")
+		}
+		if v.RelativeTolerance < 0 {
+			return nil, fieldError(node, path, "relativeTolerance", "must not be negative")
+		}
+		return &DormandPrinceIntegrator{AbsoluteTolerance: v.AbsoluteTolerance, RelativeTolerance: v.RelativeTolerance}, nil
+	case *sceneType:
+		switch v.Type {
+		case "euler":
+			return EulerIntegrator{}, nil
+		case "semi-implicit-euler":
+			return SemiImplicitEulerIntegrator{}, nil
+		case "velocity-verlet":
+			return VelocityVerletIntegrator{}, nil
+		case "leapfrog":
+			return LeapfrogIntegrator{}, nil
+		case "yoshida":
+			return YoshidaIntegrator{}, nil
+		case "boris":
+			return BorisIntegrator{}, nil
+		case "runge-kutta":
+			return RungeKuttaIntegrator{}, nil
+		}
+	}
+	return FrozenRungeKuttaIntegrator{}, nil
+}
+
+func getBoundary(e sceneElement, path string) (Boundary, error) {
+	node := e.node
+	value, err := e.decode(path, sceneBoundaries)
+	if err != nil {
+		return nil, err
+	}
+	switch v := value.(type) {
+	case *sceneReflectiveBoundary:
+		if err := checkBox(node, path, v.MinX, v.MinY, v.MaxX, v.MaxY); err != nil {
+			return nil, err
+		}
+		restitution, err := getSceneRestitution(node, path, v.Restitution)
+		if err != nil {
+			return nil, err
+		}
+		return ReflectiveBoundary{
+			MinX: v.MinX, MinY: v.MinY, MaxX: v.MaxX, MaxY: v.MaxY, MinZ: v.MinZ, MaxZ: v.MaxZ,
+			Restitution: restitution, Friction: v.Friction,
+		}, nil
+	case *sceneBox:
+		if err := checkBox(node, path, v.MinX, v.MinY, v.MaxX, v.MaxY); err != nil {
+			return nil, err
+		}
+		if v.Type == "periodic" {
+			return PeriodicBoundary{MinX: v.MinX, MinY: v.MinY, MaxX: v.MaxX, MaxY: v.MaxY, MinZ: v.MinZ, MaxZ: v.MaxZ}, nil
+		}
+		return AbsorbingBoundary{MinX: v.MinX, MinY: v.MinY, MaxX: v.MaxX, MaxY: v.MaxY, MinZ: v.MinZ, MaxZ: v.MaxZ}, nil
+	}
+	return UnboundedBoundary{}, nil
+}
+
+func checkBox(node *yaml.Node, path string, minX, minY, maxX, maxY float64) error {
+	if maxX <= minX {
+		return fieldError(node, path, "maxX", "must be greater than minX")
+	}
+	if maxY <= minY {
+		return fieldError(node, path, "maxY", "must be greater than minY")
+	}
+	return nil
+}
+
+func getGravitySource(e sceneElement, path string, settings Settings) (GravitySource, error) {
+	node := e.node
+	value, err := e.decode(path, sceneGravitySources)
+	if err != nil {
+		return nil, err
+	}
+	switch v := value.(type) {
+	case *sceneLinearSource:
+		if v.X0 == v.X1 && v.Y0 == v.Y1 {
+			return nil, fieldError(node, path, "x1", "the line needs two distinct points")
+		}
+		line := algebra.Line{X0: v.X0, Y0: v.Y0, X1: v.X1, Y1: v.Y1}
+		if v.Type == "segment" {
+			return &SegmentGravitySource{settings, line}, nil
+		}
+		return &LinearGravitySource{settings, line}, nil
+	case *scenePointSource:
+		source := &PointGravitySource{
+			Settings:  settings,
+			Point:     algebra.Point3D{X: v.X, Y: v.Y, Z: v.Z},
+			GM:        v.GM,
+			Mass:      v.Mass,
+			Softening: v.Softening,
+			Repulsive: v.Repulsive,
+		}
+		if v.Mode != "" {
+			found := false
+			for mode, name := range pointGravityModeNames {
+				if name == v.Mode {
+					source.Mode = mode
+					found = true
+				}
+			}
+			if !found {
+				return nil, fieldError(node, path, "mode", "must be one of "+getNames(pointGravityModeNames))
+			}
+		}
+		for _, field := range []struct {
+			name  string
+			value float64
+		}{{"gm", v.GM}, {"mass", v.Mass}, {"softening", v.Softening}} {
+			if field.value < 0 {
+				return nil, fieldError(node, path, field.name, "must not be negative")
+			}
+		}
+		return source, nil
+	case *scenePlaneSource:
+		normal := v.Normal.toPoint3D()
+		if normal.Length() == 0 {
+			return nil, fieldError(node, path, "normal", "must not be zero")
+		}
+		return &PlaneGravitySource{settings, algebra.Plane{Point: v.Point.toPoint3D(), Normal: normal}}, nil
+	case *scenePolygonSource:
+		polygon, err := getPolygon(node, path, v.Vertices)
+		if err != nil {
+			return nil, err
+		}
+		return &PolygonGravitySource{settings, polygon}, nil
+	case *sceneFilledPolygonSource:
+		polygon, err := getPolygon(node, path, v.Vertices)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkNotNegative(node, path, "gm", v.GM, "mass", v.Mass, "softening", v.Softening); err != nil {
+			return nil, err
+		}
+		return &FilledPolygonGravitySource{settings, polygon, v.Z, v.GM, v.Mass, v.Softening}, nil
+	case *sceneBallSource:
+		if v.Radius <= 0 {
+			return nil, fieldError(node, path, "radius", "must be positive")
+		}
+		if err := checkNotNegative(node, path, "gm", v.GM, "mass", v.Mass); err != nil {
+			return nil, err
+		}
+		return &BallGravitySource{settings, algebra.Point{X: v.X, Y: v.Y}, v.Z, v.Radius, v.GM, v.Mass}, nil
+	case *sceneCircleSource:
+		if v.Radius <= 0 {
+			return nil, fieldError(node, path, "radius", "must be positive")
+		}
+		if err := checkNotNegative(node, path, "gm", v.GM, "mass", v.Mass, "softening", v.Softening); err != nil {
+			return nil, err
+		}
+		if v.Type == "disk" {
+			return &DiskGravitySource{settings, algebra.Point{X: v.X, Y: v.Y}, v.Z, v.Radius, v.GM, v.Mass, v.Softening}, nil
+		}
+		return &RingGravitySource{settings, algebra.Point{X: v.X, Y: v.Y}, v.Z, v.Radius, v.GM, v.Mass, v.Softening}, nil
+	}
+	return nil, nil
+}
+
+func getPolygon(node *yaml.Node, path string, vertices []scenePoint) (algebra.Polygon, error) {
+	polygon := algebra.Polygon{}
+	for _, v := range vertices {
+		polygon.Vertices = append(polygon.Vertices, algebra.Point{X: v.X, Y: v.Y})
+	}
+	if len(polygon.Vertices) < 3 {
+		return polygon, fieldError(node, path, "vertices", "the polygon needs at least 3 vertices")
+	}
+	if polygon.Area() == 0 {
+		return polygon, fieldError(node, path, "vertices", "the polygon must not have zero area")
+	}
+	return polygon, nil
+}
+
+func newScenePoints(polygon algebra.Polygon) []scenePoint {
+	points := []scenePoint{}
+	for _, v := range polygon.Vertices {
+		points = append(points, scenePoint{v.X, v.Y})
+	}
+	return points
+}
+
+func getForceElement(e sceneElement, path string, bodies int) (ForceElement, error) {
+	node := e.node
+	value, err := e.decode(path, sceneForces)
+	if err != nil {
+		return nil, err
+	}
+	switch v := value.(type) {
+	case *sceneSpring:
+		if err := checkPair(node, path, v.First, v.Second, bodies); err != nil {
+			return nil, err
+		}
+		if err := checkNotNegative(node, path, "stiffness", v.Stiffness, "restLength", v.RestLength); err != nil {
+			return nil, err
+		}
+		return Spring{First: v.First, Second: v.Second, Stiffness: v.Stiffness, RestLength: v.RestLength}, nil
+	case *sceneAnchoredSpring:
+		if err := checkBody(node, path, "body", v.Body, bodies); err != nil {
+			return nil, err
+		}
+		if err := checkNotNegative(node, path, "stiffness", v.Stiffness, "restLength", v.RestLength); err != nil {
+			return nil, err
+		}
+		return AnchoredSpring{Body: v.Body, Anchor: v.Anchor.toPoint3D(), Stiffness: v.Stiffness, RestLength: v.RestLength}, nil
+	case *sceneDamper:
+		if err := checkPair(node, path, v.First, v.Second, bodies); err != nil {
+			return nil, err
+		}
+		if err := checkNotNegative(node, path, "coefficient", v.Coefficient); err != nil {
+			return nil, err
+		}
+		return Damper{First: v.First, Second: v.Second, Coefficient: v.Coefficient}, nil
+	case *sceneDrag:
+		if err := checkBody(node, path, "body", v.Body, bodies); err != nil {
+			return nil, err
+		}
+		if err := checkNotNegative(node, path, "coefficient", v.Coefficient); err != nil {
+			return nil, err
+		}
+		if v.Type == "quadratic-drag" {
+			return QuadraticDrag{Body: v.Body, Coefficient: v.Coefficient}, nil
+		}
+		return LinearDrag{Body: v.Body, Coefficient: v.Coefficient}, nil
+	}
+	return nil, nil
+}
+
+func getConstraint(e sceneElement, path string, bodies int) (Constraint, error) {
+	node := e.node
+	value, err := e.decode(path, sceneConstraints)
+	if err != nil {
+		return nil, err
+	}
+	switch v := value.(type) {
+	case *sceneDistance:
+		if err := checkPair(node, path, v.First, v.Second, bodies); err != nil {
+			return nil, err
+		}
+		if err := checkNotNegative(node, path, "length", v.Length); err != nil {
+			return nil, err
+		}
+		if v.Type == "max-distance" {
+			return MaxDistanceConstraint{First: v.First, Second: v.Second, Length: v.Length}, nil
+		}
+		return DistanceConstraint{First: v.First, Second: v.Second, Length: v.Length}, nil
+	case *sceneFixedPoint:
+		if err := checkBody(node, path, "body", v.Body, bodies); err != nil {
+			return nil, err
+		}
+		return FixedPointConstraint{Body: v.Body, Point: v.Point.toPoint3D()}, nil
+	}
+	return nil, nil
+}
+
+func checkBody(node *yaml.Node, path, name string, index, bodies int) error {
+	if index < 0 || index >= bodies {
+		return fieldError(node, path, name, fmt.Sprintf("no body with index %d", index))
+	}
+	return nil
+}
+
+func checkPair(node *yaml.Node, path string, first, second, bodies int) error {
+	if err := checkBody(node, path, "first", first, bodies); err != nil {
+		return err
+	}
+	if err := checkBody(node, path, "second", second, bodies); err != nil {
+		return err
+	}
+	if first == second {
+		return fieldError(node, path, "second", "must be a different body than first")
+	}
+	return nil
+}
+
+// checkNotNegative takes pairs of field names and values
+func checkNotNegative(node *yaml.Node, path string, fields ...interface{}) error {
+	for i := 0; i+1 < len(fields); i += 2 {
+		if fields[i+1].(float64) < 0 {
+			return fieldError(node, path, fields[i].(string), "must not be negative")
+		}
+	}
+	return nil
+}
+
+// decodeElement decodes a polymorphic entry into the value created by the
+// factory of its type
+func decodeElement(node *yaml.Node, path string, factories map[string]func() interface{}) (interface{}, error) {
+	node = resolveAlias(node)
+	if node.Kind != yaml.MappingNode {
+		return nil, nodeError(node, path, "expected an object")
+	}
+	typeNode := getField(node, "type")
+	if typeNode == nil {
+		return nil, nodeError(node, path+".type", "missing")
+	}
+	factory, ok := factories[typeNode.Value]
+	if !ok {
+		return nil, nodeError(typeNode, path+".type", fmt.Sprintf("unknown type %q, must be one of %s", typeNode.Value, getTypeNames(factories)))
+	}
+	value := factory()
+	if err := decodeNode(node, path, value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// decodeNode decodes the node into out after checking that every key matches
+// a field and every value has the expected kind
+func decodeNode(node *yaml.Node, path string, out interface{}) error {
+	if err := checkNode(node, path, reflect.TypeOf(out).Elem()); err != nil {
+		return err
+	}
+	return node.Decode(out)
+}
+
+var sceneElementType = reflect.TypeOf(sceneElement{})
+
+func checkNode(node *yaml.Node, path string, t reflect.Type) error {
+
+	node = resolveAlias(node)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == sceneElementType {
+		// Checked when decoding the element
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return nodeError(node, path, "expected an object")
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			field, ok := getStructField(t, key.Value)
+			if !ok {
+				return nodeError(key, joinPath(path, key.Value), "unknown field")
+			}
+			if err := checkNode(node.Content[i+1], joinPath(path, key.Value), field.Type); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return nodeError(node, path, "expected a list")
+		}
+		for i := range node.Content {
+			if err := checkNode(node.Content[i], fmt.Sprintf("%s[%d]", path, i), t.Elem()); err != nil {
+				return err
+			}
+		}
+	case reflect.Float64:
+		if node.Kind != yaml.ScalarNode || (node.ShortTag() != "!!float" && node.ShortTag() != "!!int") {
+			return nodeError(node, path, "expected a number")
+		}
+		// YAML, unlike JSON, can write infinities and NaN
+		var value float64
+		if err := node.Decode(&value); err != nil || math.IsInf(value, 0) || math.IsNaN(value) {
+			return nodeError(node, path, "must be finite")
+		}
+	case reflect.Int:
+		if node.Kind != yaml.ScalarNode || node.ShortTag() != "!!int" {
+			return nodeError(node, path, "expected an integer")
+		}
+	case reflect.String:
+		if node.Kind != yaml.ScalarNode || node.ShortTag() != "!!str" {
+			return nodeError(node, path, "expected a string")
+		}
+	case reflect.Bool:
+		if node.Kind != yaml.ScalarNode || node.ShortTag() != "!!bool" {
+			return nodeError(node, path, "expected true or false")
+		}
+	}
+	return nil
+}
+
+func getStructField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == key {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// getField returns the value of the key in a mapping node, or nil
+func getField(node *yaml.Node, key string) *yaml.Node {
+	node = resolveAlias(node)
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return resolveAlias(node.Content[i+1])
+		}
+	}
+	return nil
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+// fieldError points at the value of the field, or at the object when the
+// field is left out
+func fieldError(node *yaml.Node, path, field, message string) error {
+	if value := getField(node, field); value != nil {
+		node = value
+	}
+	return nodeError(node, joinPath(path, field), message)
+}
+
+func nodeError(node *yaml.Node, path, message string) error {
+	if path == "" {
+		path = "scene"
+	}
+	if node == nil {
+		return &SceneError{Field: path, Message: message}
+	}
+	return &SceneError{Line: node.Line, Column: node.Column, Field: path, Message: message}
+}
+
+func joinPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+func getTypeNames(factories map[string]func() interface{}) string {
+	names := []string{}
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func getNames(values interface{}) string {
+	names := []string{}
+	iterator := reflect.ValueOf(values).MapRange()
+	for iterator.Next() {
+		names = append(names, iterator.Value().String())
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func newSceneFile(state State) (sceneFile, error) {
+
+	s := state.Settings
+	file := sceneFile{
+		Settings: sceneSettings{
+			GravityAcceleration:   s.GravityAcceleration,
+			DeltaTime:             s.DeltaTime,
+			DefaultRadius:         s.DefaultRadius,
+			GravitationalConstant: s.GravitationalConstant,
+			Softening:             s.Softening,
+			CoulombConstant:       s.CoulombConstant,
+			Theta:                 s.Theta,
+			Drag:                  s.Drag,
+			ConstraintIterations:  s.ConstraintIterations,
+			Workers:               s.Workers,
+		},
+		Bodies: []sceneBody{},
+	}
+
+	if s.CollisionResponse != NoCollisions {
+		name, ok := collisionResponseNames[s.CollisionResponse]
+		if !ok {
+			return sceneFile{}, fmt.Errorf("cannot save collision response %d", s.CollisionResponse)
+		}
+		file.Settings.CollisionResponse = name
+	}
+
+	if s.Integrator != nil {
+		var value interface{}
+		switch i := s.Integrator.(type) {
+		case FrozenRungeKuttaIntegrator:
+			value = sceneType{"frozen-runge-kutta"}
+		case EulerIntegrator:
+			value = sceneType{"euler"}
+		case SemiImplicitEulerIntegrator:
+			value = sceneType{"semi-implicit-euler"}
+		case VelocityVerletIntegrator:
+			value = sceneType{"velocity-verlet"}
+		case LeapfrogIntegrator:
+			value = sceneType{"leapfrog"}
+		case YoshidaIntegrator:
+			value = sceneType{"yoshida"}
+		case BorisIntegrator:
+			value = sceneType{"boris"}
+		case RungeKuttaIntegrator:
+			value = sceneType{"runge-kutta"}
+		case *DormandPrinceIntegrator:
+			value = sceneDormandPrince{"dormand-prince", i.AbsoluteTolerance, i.RelativeTolerance}
+		default:
+			return sceneFile{}, fmt.Errorf("cannot save integrator of type %T", s.Integrator)
+		}
+		file.Settings.Integrator = &sceneElement{value: value}
+	}
+
+	if s.Boundary != nil {
+		var value interface{}
+		switch b := s.Boundary.(type) {
+		case ReflectiveBoundary:
+			value = sceneReflectiveBoundary{"reflective", b.MinX, b.MinY, b.MaxX, b.MaxY, b.MinZ, b.MaxZ, newSceneRestitution(b.Restitution), b.Friction}
+		case PeriodicBoundary:
+			value = sceneBox{"periodic", b.MinX, b.MinY, b.MaxX, b.MaxY, b.MinZ, b.MaxZ}
+		case AbsorbingBoundary:
+			value = sceneBox{"absorbing", b.MinX, b.MinY, b.MaxX, b.MaxY, b.MinZ, b.MaxZ}
+		case UnboundedBoundary:
+			value = sceneType{"unbounded"}
+		default:
+			return sceneFile{}, fmt.Errorf("cannot save boundary of type %T", s.Boundary)
+		}
+		file.Settings.Boundary = &sceneElement{value: value}
+	}
+
+	if s.BroadPhase != nil {
+		var value interface{}
+		switch b := s.BroadPhase.(type) {
+		case BruteForceBroadPhase:
+			value = sceneType{"brute-force"}
+		case SpatialHashBroadPhase:
+			value = sceneSpatialHash{"spatial-hash", b.CellSize}
+		case SweepAndPruneBroadPhase:
+			value = sceneType{"sweep-and-prune"}
+		default:
+			return sceneFile{}, fmt.Errorf("cannot save broad phase of type %T", s.BroadPhase)
+		}
+		file.Settings.BroadPhase = &sceneElement{value: value}
+	}
+
+	for _, b := range state.Bodies {
+		file.Bodies = append(file.Bodies, sceneBody{
+			X: b.X, Y: b.Y, Z: b.Z,
+			VX: b.VX, VY: b.VY, VZ: b.VZ,
+			Mass: b.Mass, Radius: b.Radius, Charge: b.Charge, Restitution: newSceneRestitution(b.Restitution),
+		})
+	}
+
+	for _, g := range state.GravitySources {
+		var value interface{}
+		switch g := g.(type) {
+		case *LinearGravitySource:
+			value = sceneLinearSource{"linear", g.Line.X0, g.Line.Y0, g.Line.X1, g.Line.Y1}
+		case *PointGravitySource:
+			mode, ok := pointGravityModeNames[g.Mode]
+			if !ok {
+				return sceneFile{}, fmt.Errorf("cannot save point gravity mode %d", g.Mode)
+			}
+			if g.Mode == InverseSquareGravity {
+				mode = ""
+			}
+			value = scenePointSource{"point", g.Point.X, g.Point.Y, g.Point.Z, mode, g.GM, g.Mass, g.Softening, g.Repulsive}
+		case *PlaneGravitySource:
+			value = scenePlaneSource{"plane", newSceneVector(g.Plane.Point), newSceneVector(g.Plane.Normal)}
+		case *SegmentGravitySource:
+			value = sceneLinearSource{"segment", g.Segment.X0, g.Segment.Y0, g.Segment.X1, g.Segment.Y1}
+		case *PolygonGravitySource:
+			value = scenePolygonSource{"polygon", newScenePoints(g.Polygon)}
+		case *FilledPolygonGravitySource:
+			value = sceneFilledPolygonSource{"filled-polygon", newScenePoints(g.Polygon), g.Z, g.GM, g.Mass, g.Softening}
+		case *BallGravitySource:
+			value = sceneBallSource{"ball", g.Center.X, g.Center.Y, g.Z, g.Radius, g.GM, g.Mass}
+		case *RingGravitySource:
+			value = sceneCircleSource{"ring", g.Center.X, g.Center.Y, g.Z, g.Radius, g.GM, g.Mass, g.Softening}
+		case *DiskGravitySource:
+			value = sceneCircleSource{"disk", g.Center.X, g.Center.Y, g.Z, g.Radius, g.GM, g.Mass, g.Softening}
+		default:
+			return sceneFile{}, fmt.Errorf("cannot save gravity source of type %T", g)
+		}
+		file.GravitySources = append(file.GravitySources, sceneElement{value: value})
+	}
+
+	for _, f := range state.Forces {
+		var value interface{}
+		switch f := f.(type) {
+		case Spring:
+			value = sceneSpring{"spring", f.First, f.Second, f.Stiffness, f.RestLength}
+		case AnchoredSpring:
+			value = sceneAnchoredSpring{"anchored-spring", f.Body, newSceneVector(f.Anchor), f.Stiffness, f.RestLength}
+		case Damper:
+			value = sceneDamper{"damper", f.First, f.Second, f.Coefficient}
+		case LinearDrag:
+			value = sceneDrag{"linear-drag", f.Body, f.Coefficient}
+		case QuadraticDrag:
+			value = sceneDrag{"quadratic-drag", f.Body, f.Coefficient}
+		default:
+			return sceneFile{}, fmt.Errorf("cannot save force element of type %T", f)
+		}
+		file.Forces = append(file.Forces, sceneElement{value: value})
+	}
+
+	for _, c := range state.Constraints {
+		var value interface{}
+		switch c := c.(type) {
+		case DistanceConstraint:
+			value = sceneDistance{"distance", c.First, c.Second, c.Length}
+		case MaxDistanceConstraint:
+			value = sceneDistance{"max-distance", c.First, c.Second, c.Length}
+		case FixedPointConstraint:
+			value = sceneFixedPoint{"fixed-point", c.Body, newSceneVector(c.Point)}
+		default:
+			return sceneFile{}, fmt.Errorf("cannot save constraint of type %T", c)
+		}
+		file.Constraints = append(file.Constraints, sceneElement{value: value})
+	}
+
+	for _, e := range state.ElectricFields {
+		u, ok := e.(UniformElectricField)
+		if !ok {
+			return sceneFile{}, fmt.Errorf("cannot save electric field of type %T", e)
+		}
+		file.ElectricFields = append(file.ElectricFields, sceneElement{value: sceneUniformElectricField{"uniform", newSceneVector(u.Field)}})
+	}
+
+	if state.MagneticField != (algebra.Point3D{}) {
+		magneticField := newSceneVector(state.MagneticField)
+		file.MagneticField = &magneticField
+	}
+
+	return file, nil
+}
@@ -0,0 +1,195 @@
+package dynamics
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rpagliuca/go-physics/pkg/algebra"
+	"github.com/stretchr/testify/assert"
+)
+
+func sceneState() State {
+	settings := SETTINGS
+	settings.Integrator = &DormandPrinceIntegrator{RelativeTolerance: 1e-9}
+	settings.Boundary = PeriodicBoundary{MaxX: 100, MaxY: 200}
+	settings.CollisionResponse = InelasticCollisions
+	settings.BroadPhase = SpatialHashBroadPhase{CellSize: 20}
+	settings.GravitationalConstant = 0.5
+	settings.CoulombConstant = 2
+	settings.Softening = 0.1
+	return State{
+		Settings: settings,
+		Bodies: []BodyState{
+			{X: 1, Y: 2, Z: 3, VX: 4, VY: 5, VZ: 6, Mass: 7, Radius: 8, Charge: -9, Restitution: 0.5},
+			{X: 0.1, Y: 1e-9, Restitution: NO_RESTITUTION},
+		},
+		GravitySources: []GravitySource{
+			&LinearGravitySource{settings, algebra.Line{X0: 0, Y0: 1000, X1: 1000, Y1: 1000}},
+			&PointGravitySource{Settings: settings, Point: algebra.Point3D{X: 5, Y: 6, Z: 1}, GM: 100, Softening: 2, Repulsive: true},
+			&PointGravitySource{Settings: settings, Point: algebra.Point3D{X: 5, Y: 6}, Mode: ConstantMagnitudeGravity},
+			&PlaneGravitySource{settings, algebra.Plane{Point: algebra.Point3D{Z: -10}, Normal: algebra.Point3D{Z: 1}}},
+			&SegmentGravitySource{settings, algebra.Line{X0: 1, Y0: 2, X1: 3, Y1: 4}},
+			&PolygonGravitySource{settings, square(10)},
+			&FilledPolygonGravitySource{settings, square(2), 1, 0, 30, 0.5},
+			&BallGravitySource{settings, algebra.Point{X: 5, Y: 6}, 0, 2, 40, 0},
+			&RingGravitySource{settings, algebra.Point{X: 5, Y: 6}, -1, 3, 0, 50, 0.1},
+			&DiskGravitySource{settings, algebra.Point{X: 5, Y: 6}, 2, 3, 60, 0, 0},
+		},
+		Forces: []ForceElement{
+			Spring{First: 0, Second: 1, Stiffness: 3, RestLength: 4},
+			AnchoredSpring{Body: 1, Anchor: algebra.Point3D{X: 1}, Stiffness: 2, RestLength: 1},
+			Damper{First: 1, Second: 0, Coefficient: 0.1},
+			LinearDrag{Body: 0, Coefficient: 0.2},
+			QuadraticDrag{Body: 1, Coefficient: 0.3},
+		},
+		Constraints: []Constraint{
+			FixedPointConstraint{Body: 0, Point: algebra.Point3D{X: 1, Y: 2, Z: 3}},
+			DistanceConstraint{First: 0, Second: 1, Length: 5},
+			MaxDistanceConstraint{First: 1, Second: 0, Length: 6},
+		},
+		ElectricFields: []ElectricField{UniformElectricField{Field: algebra.Point3D{X: 1, Y: -1}}},
+		MagneticField:  algebra.Point3D{Z: 0.5},
+	}
+}
+
+func TestSceneRoundTrip(t *testing.T) {
+
+	for _, save := range []func(*bytes.Buffer, State) error{
+		func(b *bytes.Buffer, s State) error { return SaveScene(b, s) },
+		func(b *bytes.Buffer, s State) error { return SaveSceneYAML(b, s) },
+	} {
+		var buffer bytes.Buffer
+		assert.NoError(t, save(&buffer, sceneState()))
+
+		loaded, err := LoadScene(&buffer)
+
+		assert.NoError(t, err)
+		assert.Equal(t, sceneState(), loaded)
+	}
+}
+
+func TestSceneDefaults(t *testing.T) {
+
+	s, err := LoadScene(strings.NewReader(`
+settings:
+  deltaTime: 0.5
+bodies:
+  - {x: 1, y: 2}
+  - {x: 3, y: 4, restitution: 0}
+`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, State{
+		Settings: Settings{DeltaTime: 0.5},
+		Bodies:   []BodyState{{X: 1, Y: 2}, {X: 3, Y: 4, Restitution: NO_RESTITUTION}},
+	}, s)
+
+	// Boundaries follow the same convention as bodies
+	for restitution, expected := range map[string]float64{"": 0, ", restitution: 0": NO_RESTITUTION, ", restitution: 0.5": 0.5} {
+		s, err = LoadScene(strings.NewReader("settings: {deltaTime: 1, boundary: {type: reflective, minX: 0, minY: 0, maxX: 1, maxY: 1" + restitution + "}}\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, ReflectiveBoundary{MaxX: 1, MaxY: 1, Restitution: expected}, s.Settings.Boundary)
+
+		var buffer bytes.Buffer
+		assert.NoError(t, SaveScene(&buffer, s))
+		loaded, err := LoadScene(&buffer)
+		assert.NoError(t, err)
+		assert.Equal(t, s, loaded)
+	}
+}
+
+func TestSceneErrors(t *testing.T) {
+
+	cases := []struct {
+		scene string
+		err   string
+	}{
+		{"settings: {deltaTime: 1}\nbodies:\n  - {x: 1, y: 2, mass: -1}\n", "line 3, column 24: bodies[0].mass: must not be negative"},
+		{"settings: {deltaTime: 1}\nbodies:\n  - {x: 1, y: 2, speed: 1}\n", "line 3, column 18: bodies[0].speed: unknown field"},
+		{"settings: {deltaTime: 1}\nbodies:\n  - {x: one}\n", "line 3, column 9: bodies[0].x: expected a number"},
+		{"settings: {deltaTime: .inf}\n", "line 1, column 23: settings.deltaTime: must be finite"},
+		{"settings: {deltaTime: 1, gravityAcceleration: -.Inf}\n", "line 1, column 47: settings.gravityAcceleration: must be finite"},
+		{"settings: {deltaTime: 1}\nbodies:\n  - {x: .nan, y: 2}\n", "line 3, column 9: bodies[0].x: must be finite"},
+		{"settings: {deltaTime: 1}\ngravitySources:\n  - {type: ball, x: 0, y: 0, radius: 1, mass: .NaN}\n", "line 3, column 47: gravitySources[0].mass: must be finite"},
+		{"settings: {deltaTime: 0}\n", "line 1, column 23: settings.deltaTime: must be positive"},
+		{"settings: {}\n", "line 1, column 11: settings.deltaTime: must be positive"},
+		{"settings: {deltaTime: 1, boundary: {type: reflective, minX: 0, minY: 0, maxX: 1, maxY: 1, restitution: -1}}\n", "line 1, column 104: settings.boundary.restitution: must not be negative"},
+		{"settings: {deltaTime: 1, collisionResponse: sticky}\n", "line 1, column 45: settings.collisionResponse: must be one of elastic, inelastic, merging, none"},
+		{"settings: {deltaTime: 1, integrator: {type: magic}}\n", "line 1, column 45: settings.integrator.type: unknown type \"magic\", must be one of boris, dormand-prince, euler, frozen-runge-kutta, leapfrog, runge-kutta, semi-implicit-euler, velocity-verlet, yoshida"},
+		{"settings: {deltaTime: 1}\ngravitySources:\n  - type: point\n    mode: constant\n", "line 4, column 11: gravitySources[0].mode: must be one of constant-magnitude, inverse-square"},
+		{"settings: {deltaTime: 1}\ngravitySources:\n  - type: plane\n    normal: {x: 0, y: 0, z: 0}\n", "line 4, column 13: gravitySources[0].normal: must not be zero"},
+		{"settings: {deltaTime: 1}\ngravitySources:\n  - type: polygon\n    vertices: [{x: 0, y: 0}, {x: 1, y: 1}]\n", "line 4, column 15: gravitySources[0].vertices: the polygon needs at least 3 vertices"},
+		{"settings: {deltaTime: 1}\ngravitySources:\n  - type: filled-polygon\n    vertices: [{x: 0, y: 0}, {x: 1, y: 1}, {x: 2, y: 2}]\n", "line 4, column 15: gravitySources[0].vertices: the polygon must not have zero area"},
+		{"settings: {deltaTime: 1}\ngravitySources:\n  - {type: ball, x: 0, y: 0, radius: 0}\n", "line 3, column 38: gravitySources[0].radius: must be positive"},
+		{"settings: {deltaTime: 1}\ngravitySources:\n  - {type: ring, x: 0, y: 0, radius: 1, softening: -1}\n", "line 3, column 52: gravitySources[0].softening: must not be negative"},
+		{"settings: {deltaTime: 1}\ngravitySources:\n  - x: 1\n", "line 3, column 5: gravitySources[0].type: missing"},
+		{"settings: {deltaTime: 1}\ngravitySources:\n  - {type: linear, width: 3}\n", "line 3, column 20: gravitySources[0].width: unknown field"},
+		{"settings: {deltaTime: 1}\nbodies: [{x: 0, y: 0}]\nforces:\n  - {type: spring, first: 0, second: 1}\n", "line 4, column 38: forces[0].second: no body with index 1"},
+		{"settings: {deltaTime: 1}\nbodies: [{x: 0, y: 0}]\nconstraints:\n  - {type: fixed-point, body: 0.5}\n", "line 4, column 31: constraints[0].body: expected an integer"},
+		{"settings: {deltaTime: 1}\nbodies: {x: 0}\n", "line 2, column 9: bodies: expected a list"},
+		{"[1, 2]", "line 1, column 1: scene: expected an object"},
+	}
+
+	for _, c := range cases {
+		_, err := LoadScene(strings.NewReader(c.scene))
+		if assert.Error(t, err, c.scene) {
+			assert.Equal(t, c.err, err.Error(), c.scene)
+		}
+	}
+}
+
+func TestSceneJSONErrors(t *testing.T) {
+
+	_, err := LoadScene(strings.NewReader(`{
+  "settings": {"deltaTime": 1},
+  "bodies": [
+    {"x": 1, "y": 2},
+    {"x": 1, "y": 2, "restitution": -0.5}
+  ]
+}`))
+
+	assert.EqualError(t, err, "line 5, column 37: bodies[1].restitution: must not be negative")
+}
+
+func TestSaveUnknownElement(t *testing.T) {
+
+	s := sceneState()
+	s.ElectricFields = []ElectricField{ElectricFieldFunc{}}
+
+	err := SaveScene(&bytes.Buffer{}, s)
+
+	assert.EqualError(t, err, "cannot save electric field of type dynamics.ElectricFieldFunc")
+}
+
+func TestExampleScenes(t *testing.T) {
+
+	files, _ := filepath.Glob("../../cmd/orbit-3d-opengl/scenes/*")
+	assert.NotEmpty(t, files)
+
+	for _, file := range files {
+		f, err := os.Open(file)
+		assert.NoError(t, err)
+		s, err := LoadScene(f)
+		f.Close()
+		assert.NoError(t, err, file)
+		assert.NotEmpty(t, s.Bodies, file)
+		UpdateState(s)
+	}
+}
+
+func TestNewIntegrator(t *testing.T) {
+
+	integrator, err := NewIntegrator("dormand-prince")
+	assert.NoError(t, err)
+	assert.Equal(t, &DormandPrinceIntegrator{}, integrator)
+
+	integrator, err = NewIntegrator("leapfrog")
+	assert.NoError(t, err)
+	assert.Equal(t, LeapfrogIntegrator{}, integrator)
+
+	_, err = NewIntegrator("verlet")
+	assert.EqualError(t, err, "unknown integrator \"verlet\", must be one of boris, dormand-prince, euler, frozen-runge-kutta, leapfrog, runge-kutta, semi-implicit-euler, velocity-verlet, yoshida")
+}
@@ -0,0 +1,135 @@
+package dynamics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"strconv"
+)
+
+type TrajectoryFormat int
+
+const (
+	CSVTrajectory TrajectoryFormat = iota
+	// NDJSONTrajectory writes one JSON object per line
+	NDJSONTrajectory
+)
+
+var trajectoryColumns = []string{"step", "time", "body", "x", "y", "z", "vx", "vy", "vz"}
+var energyColumns = []string{"kinetic", "total"}
+
+// TrajectoryRecorder streams the time, position and velocity of every body.
+// Flush must be called when the simulation ends, even early.
+type TrajectoryRecorder struct {
+	Format TrajectoryFormat
+	// Every records one of Every steps, according to State.Step. Every step
+	// is recorded when zero.
+	Every int
+	// Energy adds the kinetic energy of the body and the total energy of
+	// the state
+	Energy bool
+
+	w      *bufio.Writer
+	row    []byte
+	header bool
+	err    error
+}
+
+func NewTrajectoryRecorder(w io.Writer, format TrajectoryFormat, every int) *TrajectoryRecorder {
+	return &TrajectoryRecorder{Format: format, Every: every, w: bufio.NewWriter(w)}
+}
+
+// Record writes the bodies of the state when its step is sampled. Errors are
+// sticky, like in bufio.Writer.
+func (r *TrajectoryRecorder) Record(state State) error {
+	if r.err != nil {
+		return r.err
+	}
+	if r.Every > 1 && state.Step%r.Every != 0 {
+		return nil
+	}
+
+	columns := trajectoryColumns
+	if r.Energy {
+		columns = append(append([]string{}, trajectoryColumns...), energyColumns...)
+	}
+	if r.Format == CSVTrajectory && !r.header {
+		r.row = r.row[:0]
+		for i, c := range columns {
+			if i > 0 {
+				r.row = append(r.row, ',')
+			}
+			r.row = append(r.row, c...)
+		}
+		r.write()
+		r.header = true
+	}
+
+	total := 0.0
+	if r.Energy {
+		total = state.TotalEnergy()
+	}
+	for i, b := range state.Bodies {
+		values := []float64{state.Time, b.X, b.Y, b.Z, b.VX, b.VY, b.VZ}
+		if r.Energy {
+			values = append(values, b.GetMass()*(b.VX*b.VX+b.VY*b.VY+b.VZ*b.VZ)/2, total)
+		}
+		r.row = r.row[:0]
+		if r.Format == NDJSONTrajectory {
+			r.row = append(r.row, '{')
+		}
+		r.appendKey(columns[0])
+		r.row = strconv.AppendInt(r.row, int64(state.Step), 10)
+		r.appendKey(columns[1])
+		r.row = appendNumber(r.row, values[0], r.Format)
+		r.appendKey(columns[2])
+		r.row = strconv.AppendInt(r.row, int64(i), 10)
+		for j, v := range values[1:] {
+			r.appendKey(columns[j+3])
+			r.row = appendNumber(r.row, v, r.Format)
+		}
+		if r.Format == NDJSONTrajectory {
+			r.row = append(r.row, '}')
+		}
+		r.write()
+	}
+	return r.err
+}
+
+// appendKey starts a field of the row, naming it in NDJSON
+func (r *TrajectoryRecorder) appendKey(column string) {
+	if len(r.row) > 0 && r.row[len(r.row)-1] != '{' {
+		r.row = append(r.row, ',')
+	}
+	if r.Format == NDJSONTrajectory {
+		r.row = append(r.row, '"')
+		r.row = append(r.row, column...)
+		r.row = append(r.row, '"', ':')
+	}
+}
+
+func (r *TrajectoryRecorder) write() {
+	if r.err != nil {
+		return
+	}
+	r.row = append(r.row, '\n')
+	_, r.err = r.w.Write(r.row)
+}
+
+// Flush writes the buffered rows
+func (r *TrajectoryRecorder) Flush() error {
+	if r.err != nil {
+		return r.err
+	}
+	r.err = r.w.Flush()
+	return r.err
+}
+
+// appendNumber formats floats with the shortest exact representation. JSON
+// has no representation for infinities and NaN, which are written as null.
+func appendNumber(row []byte, v float64, format TrajectoryFormat) []byte {
+	if format == NDJSONTrajectory && (math.IsInf(v, 0) || math.IsNaN(v)) {
+		return append(row, "null"...)
+	}
+	return strconv.AppendFloat(row, v, 'g', -1, 64)
+}
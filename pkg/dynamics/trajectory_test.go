@@ -0,0 +1,104 @@
+package dynamics
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func trajectoryState() State {
+	settings := SETTINGS
+	settings.Boundary = UnboundedBoundary{}
+	settings.DeltaTime = 0.5
+	return State{
+		Settings: settings,
+		Bodies: []BodyState{
+			{X: 1, Y: 2, VX: 1},
+			{X: 3, Y: 4, VY: -2, Mass: 2},
+		},
+	}
+}
+
+func TestTrajectoryCSV(t *testing.T) {
+
+	var buffer bytes.Buffer
+	recorder := NewTrajectoryRecorder(&buffer, CSVTrajectory, 2)
+	s := trajectoryState()
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, recorder.Record(s))
+		s = UpdateState(s)
+	}
+	assert.NoError(t, recorder.Flush())
+
+	assert.Equal(t, strings.Join([]string{
+		"step,time,body,x,y,z,vx,vy,vz",
+		"0,0,0,1,2,0,1,0,0",
+		"0,0,1,3,4,0,0,-2,0",
+		"2,1,0,2,2,0,1,0,0",
+		"2,1,1,3,2,0,0,-2,0",
+		"",
+	}, "\n"), buffer.String())
+}
+
+func TestTrajectoryNDJSONWithEnergy(t *testing.T) {
+
+	var buffer bytes.Buffer
+	recorder := NewTrajectoryRecorder(&buffer, NDJSONTrajectory, 0)
+	recorder.Energy = true
+	s := trajectoryState()
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, recorder.Record(s))
+		s = UpdateState(s)
+	}
+	assert.NoError(t, recorder.Flush())
+
+	scanner := bufio.NewScanner(&buffer)
+	rows := 0
+	for scanner.Scan() {
+		var row map[string]float64
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &row))
+		assert.Len(t, row, 11)
+		assert.Equal(t, float64(rows%2), row["body"])
+		assert.Equal(t, float64(rows/2), row["step"])
+		assert.Equal(t, 4.5, row["total"])
+		rows++
+	}
+	assert.Equal(t, 8, rows)
+}
+
+func TestTrajectoryFlushesOnlyWhenAsked(t *testing.T) {
+
+	var buffer bytes.Buffer
+	recorder := NewTrajectoryRecorder(&buffer, CSVTrajectory, 1)
+	s := trajectoryState()
+	s.Step = 1000000
+
+	assert.NoError(t, recorder.Record(s))
+	assert.Empty(t, buffer.String())
+
+	// Stopping early still writes every recorded row
+	assert.NoError(t, recorder.Flush())
+	assert.Equal(t, 3, strings.Count(buffer.String(), "\n"))
+	assert.Contains(t, buffer.String(), "\n1000000,0,0,")
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestTrajectoryWriteError(t *testing.T) {
+
+	recorder := NewTrajectoryRecorder(failingWriter{}, CSVTrajectory, 1)
+	s := trajectoryState()
+
+	assert.NoError(t, recorder.Record(s))
+	assert.EqualError(t, recorder.Flush(), "disk full")
+	assert.EqualError(t, recorder.Record(s), "disk full")
+}
@@ -0,0 +1,269 @@
+// Package validation runs dynamics.UpdateState on problems with closed form
+// solutions, measuring the global error of the integrators and how fast it
+// shrinks with the time step.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/rpagliuca/go-physics/pkg/algebra"
+	"github.com/rpagliuca/go-physics/pkg/dynamics"
+)
+
+// ROUNDING_ERROR is the smallest position error fitted by Converge, below
+// which the errors are only rounding
+const ROUNDING_ERROR = 1e-11
+
+// Problem is a scene with a single body whose exact motion is known
+type Problem struct {
+	Name     string
+	Duration float64
+	// State returns the initial scene, without integrator nor time step
+	State func() dynamics.State
+	// Solution returns the exact body at the time
+	Solution func(t float64) dynamics.BodyState
+}
+
+// Result of running a problem with a time step
+type Result struct {
+	DeltaTime float64
+	// Largest distance between the simulated and the exact body, after any
+	// step, for the positions and for the velocities
+	PositionError float64
+	VelocityError float64
+}
+
+// Convergence of the global error of an integrator on a problem
+type Convergence struct {
+	Problem string
+	Results []Result
+	// Slope of the log-log fit of the position error against the time step,
+	// zero when the errors are below ROUNDING_ERROR
+	Order float64
+}
+
+// Run integrates the problem for its duration, rounded to whole steps,
+// comparing with the solution after every step
+func Run(problem Problem, integrator dynamics.Integrator, deltaTime float64) Result {
+	state := problem.State()
+	state.Settings.Integrator = integrator
+	state.Settings.DeltaTime = deltaTime
+	result := Result{DeltaTime: deltaTime}
+	steps := int(math.Round(problem.Duration / deltaTime))
+	for i := 0; i < steps; i++ {
+		state = dynamics.UpdateState(state)
+		b := state.Bodies[0]
+		exact := problem.Solution(state.Time)
+		result.PositionError = math.Max(result.PositionError, math.Sqrt(
+			(b.X-exact.X)*(b.X-exact.X)+(b.Y-exact.Y)*(b.Y-exact.Y)+(b.Z-exact.Z)*(b.Z-exact.Z),
+		))
+		result.VelocityError = math.Max(result.VelocityError, math.Sqrt(
+			(b.VX-exact.VX)*(b.VX-exact.VX)+(b.VY-exact.VY)*(b.VY-exact.VY)+(b.VZ-exact.VZ)*(b.VZ-exact.VZ),
+		))
+	}
+	return result
+}
+
+// Converge runs the problem with each of the time steps, which must be
+// positive and take at least two distinct values to fit the order
+func Converge(problem Problem, integrator dynamics.Integrator, deltaTimes []float64) (Convergence, error) {
+	distinct := map[float64]bool{}
+	for _, deltaTime := range deltaTimes {
+		if deltaTime <= 0 {
+			return Convergence{}, fmt.Errorf("time step %g is not positive", deltaTime)
+		}
+		distinct[deltaTime] = true
+	}
+	if len(distinct) < 2 {
+		return Convergence{}, errors.New("at least two distinct time steps are needed")
+	}
+
+	c := Convergence{Problem: problem.Name}
+	var sx, sy, sxx, sxy float64
+	for _, deltaTime := range deltaTimes {
+		result := Run(problem, integrator, deltaTime)
+		c.Results = append(c.Results, result)
+		x, y := math.Log(deltaTime), math.Log(math.Max(result.PositionError, ROUNDING_ERROR))
+		sx, sy, sxx, sxy = sx+x, sy+y, sxx+x*x, sxy+x*y
+	}
+	n := float64(len(deltaTimes))
+	c.Order = (n*sxy - sx*sy) / (n*sxx - sx*sx)
+	return c, nil
+}
+
+// WriteReport writes a table with the errors and the order of convergence of
+// each problem
+func WriteReport(w io.Writer, convergences []Convergence) error {
+	for _, c := range convergences {
+		if _, err := fmt.Fprintf(w, "%s: order %.2f\n", c.Problem, c.Order); err != nil {
+			return err
+		}
+		for _, r := range c.Results {
+			_, err := fmt.Fprintf(w, "  dt %-8g position error %-12.4g velocity error %.4g\n", r.DeltaTime, r.PositionError, r.VelocityError)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Problems returns all the problems of the package
+func Problems() []Problem {
+	return []Problem{
+		Projectile(),
+		CircularOrbit(),
+		EllipticalOrbit(),
+		HarmonicOscillator(),
+		DampedOscillator(),
+	}
+}
+
+func getSettings() dynamics.Settings {
+	return dynamics.Settings{
+		GravityAcceleration: 9.8,
+		Boundary:            dynamics.UnboundedBoundary{},
+	}
+}
+
+// Projectile thrown above a horizontal LinearGravitySource
+func Projectile() Problem {
+	const vx, vy = 3, 20
+	settings := getSettings()
+	g := settings.GravityAcceleration
+	return Problem{
+		Name:     "projectile",
+		Duration: 4,
+		State: func() dynamics.State {
+			return dynamics.State{
+				Settings: settings,
+				Bodies:   []dynamics.BodyState{{Y: 10, VX: vx, VY: vy, Radius: 0.1}},
+				GravitySources: []dynamics.GravitySource{
+					&dynamics.LinearGravitySource{Settings: settings, Line: algebra.Line{X0: -1e6, X1: 1e6}},
+				},
+			}
+		},
+		Solution: func(t float64) dynamics.BodyState {
+			return dynamics.BodyState{X: vx * t, Y: 10 + vy*t - g*t*t/2, VX: vx, VY: vy - g*t}
+		},
+	}
+}
+
+// CircularOrbit of unit radius around a PointGravitySource, for one period
+func CircularOrbit() Problem {
+	settings := getSettings()
+	return Problem{
+		Name:     "circular orbit",
+		Duration: 2 * math.Pi,
+		State: func() dynamics.State {
+			return dynamics.State{
+				Settings:       settings,
+				Bodies:         []dynamics.BodyState{{X: 1, VY: 1, Radius: 0.01}},
+				GravitySources: []dynamics.GravitySource{&dynamics.PointGravitySource{Settings: settings, GM: 1}},
+			}
+		},
+		Solution: func(t float64) dynamics.BodyState {
+			return dynamics.BodyState{X: math.Cos(t), Y: math.Sin(t), VX: -math.Sin(t), VY: math.Cos(t)}
+		},
+	}
+}
+
+// EllipticalOrbit with eccentricity 0.44 around a PointGravitySource, starting
+// at the periapsis, for one period
+func EllipticalOrbit() Problem {
+	const periapsis, speed = 1.0, 1.2
+	settings := getSettings()
+	a := 1 / (2/periapsis - speed*speed)
+	e := 1 - periapsis/a
+	b := a * math.Sqrt(1-e*e)
+	n := math.Pow(a, -1.5)
+	return Problem{
+		Name:     "elliptical orbit",
+		Duration: 2 * math.Pi / n,
+		State: func() dynamics.State {
+			return dynamics.State{
+				Settings:       settings,
+				Bodies:         []dynamics.BodyState{{X: periapsis, VY: speed, Radius: 0.01}},
+				GravitySources: []dynamics.GravitySource{&dynamics.PointGravitySource{Settings: settings, GM: 1}},
+			}
+		},
+		Solution: func(t float64) dynamics.BodyState {
+			E := getEccentricAnomaly(n*t, e)
+			rate := n / (1 - e*math.Cos(E))
+			return dynamics.BodyState{
+				X:  a * (math.Cos(E) - e),
+				Y:  b * math.Sin(E),
+				VX: -a * math.Sin(E) * rate,
+				VY: b * math.Cos(E) * rate,
+			}
+		},
+	}
+}
+
+// getEccentricAnomaly solves the equation of Kepler, M = E - e sin(E), with
+// the method of Newton
+func getEccentricAnomaly(M, e float64) float64 {
+	E := M
+	for i := 0; i < 50; i++ {
+		delta := (E - e*math.Sin(E) - M) / (1 - e*math.Cos(E))
+		E -= delta
+		if math.Abs(delta) < 1e-15 {
+			break
+		}
+	}
+	return E
+}
+
+// HarmonicOscillator is a body on an AnchoredSpring of zero rest length,
+// moving along an ellipse around the anchor, for two periods
+func HarmonicOscillator() Problem {
+	problem := getOscillator(0)
+	problem.Name = "harmonic oscillator"
+	return problem
+}
+
+// DampedOscillator adds an underdamped LinearDrag to HarmonicOscillator
+func DampedOscillator() Problem {
+	problem := getOscillator(0.5)
+	problem.Name = "damped oscillator"
+	return problem
+}
+
+func getOscillator(drag float64) Problem {
+	const mass, stiffness = 2.0, 8.0
+	const x0, vy0 = 1.0, 1.0
+	settings := getSettings()
+	omega0 := math.Sqrt(stiffness / mass)
+	gamma := drag / (2 * mass)
+	omega := math.Sqrt(omega0*omega0 - gamma*gamma)
+	// Position and velocity of each axis
+	solve := func(t, x0, v0 float64) (float64, float64) {
+		decay := math.Exp(-gamma * t)
+		cos, sin := math.Cos(omega*t), math.Sin(omega*t)
+		x := decay * (x0*cos + (v0+gamma*x0)/omega*sin)
+		v := decay * (v0*cos - (omega0*omega0*x0+gamma*v0)/omega*sin)
+		return x, v
+	}
+	return Problem{
+		Duration: 4 * math.Pi / omega0,
+		State: func() dynamics.State {
+			forces := []dynamics.ForceElement{dynamics.AnchoredSpring{Body: 0, Stiffness: stiffness}}
+			if drag != 0 {
+				forces = append(forces, dynamics.LinearDrag{Body: 0, Coefficient: drag})
+			}
+			return dynamics.State{
+				Settings: settings,
+				Bodies:   []dynamics.BodyState{{X: x0, VY: vy0, Mass: mass, Radius: 0.01}},
+				Forces:   forces,
+			}
+		},
+		Solution: func(t float64) dynamics.BodyState {
+			x, vx := solve(t, x0, 0)
+			y, vy := solve(t, 0, vy0)
+			return dynamics.BodyState{X: x, Y: y, VX: vx, VY: vy}
+		},
+	}
+}
@@ -0,0 +1,122 @@
+package validation
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rpagliuca/go-physics/pkg/dynamics"
+	"github.com/stretchr/testify/assert"
+)
+
+var deltaTimes = []float64{0.02, 0.01, 0.005}
+
+func TestConvergenceOrder(t *testing.T) {
+
+	cases := []struct {
+		integrator dynamics.Integrator
+		problem    Problem
+		order      float64
+	}{
+		{dynamics.EulerIntegrator{}, Projectile(), 1},
+		{dynamics.EulerIntegrator{}, EllipticalOrbit(), 1},
+		{dynamics.FrozenRungeKuttaIntegrator{}, CircularOrbit(), 1},
+		{dynamics.FrozenRungeKuttaIntegrator{}, HarmonicOscillator(), 1},
+		{dynamics.LeapfrogIntegrator{}, EllipticalOrbit(), 2},
+		{dynamics.LeapfrogIntegrator{}, HarmonicOscillator(), 2},
+		// Leapfrog kicks with the velocity at the start of the step
+		{dynamics.LeapfrogIntegrator{}, DampedOscillator(), 1},
+		{dynamics.VelocityVerletIntegrator{}, DampedOscillator(), 2},
+		{dynamics.YoshidaIntegrator{}, CircularOrbit(), 4},
+		{dynamics.YoshidaIntegrator{}, EllipticalOrbit(), 4},
+		{dynamics.YoshidaIntegrator{}, HarmonicOscillator(), 4},
+		{dynamics.RungeKuttaIntegrator{}, CircularOrbit(), 4},
+		{dynamics.RungeKuttaIntegrator{}, EllipticalOrbit(), 4},
+		{dynamics.RungeKuttaIntegrator{}, HarmonicOscillator(), 4},
+		{dynamics.RungeKuttaIntegrator{}, DampedOscillator(), 4},
+	}
+
+	for _, c := range cases {
+		convergence, err := Converge(c.problem, c.integrator, deltaTimes)
+		assert.NoError(t, err)
+		assert.InDelta(t, c.order, convergence.Order, 0.15, "%T %s", c.integrator, c.problem.Name)
+		assert.Len(t, convergence.Results, 3)
+	}
+}
+
+func TestExactProjectile(t *testing.T) {
+
+	integrators := []dynamics.Integrator{
+		dynamics.FrozenRungeKuttaIntegrator{},
+		dynamics.LeapfrogIntegrator{},
+		dynamics.YoshidaIntegrator{},
+		dynamics.RungeKuttaIntegrator{},
+	}
+
+	for _, integrator := range integrators {
+		result := Run(Projectile(), integrator, 0.01)
+		assert.Less(t, result.PositionError, 1e-9, "%T", integrator)
+		assert.Less(t, result.VelocityError, 1e-9, "%T", integrator)
+		// Rounding errors give no order
+		convergence, err := Converge(Projectile(), integrator, deltaTimes)
+		assert.NoError(t, err)
+		assert.Equal(t, 0.0, convergence.Order, "%T", integrator)
+	}
+
+	// Nor do errors of zero, for a body at rest
+	rest := Problem{
+		Name:     "rest",
+		Duration: 1,
+		State: func() dynamics.State {
+			return dynamics.State{Settings: getSettings(), Bodies: []dynamics.BodyState{{}}}
+		},
+		Solution: func(t float64) dynamics.BodyState { return dynamics.BodyState{} },
+	}
+	convergence, err := Converge(rest, dynamics.EulerIntegrator{}, deltaTimes)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, convergence.Results[0].PositionError)
+	assert.Equal(t, 0.0, convergence.Order)
+}
+
+func TestConvergeErrors(t *testing.T) {
+
+	// A single time step, even repeated, gives no slope to fit
+	for _, d := range [][]float64{nil, {0.01}, {0.01, 0.01}} {
+		_, err := Converge(Projectile(), dynamics.LeapfrogIntegrator{}, d)
+		assert.EqualError(t, err, "at least two distinct time steps are needed")
+	}
+
+	_, err := Converge(Projectile(), dynamics.LeapfrogIntegrator{}, []float64{0.01, 0})
+	assert.EqualError(t, err, "time step 0 is not positive")
+}
+
+func assertBody(t *testing.T, expected, actual dynamics.BodyState, name string) {
+	assert.InDelta(t, expected.X, actual.X, 1e-9, name)
+	assert.InDelta(t, expected.Y, actual.Y, 1e-9, name)
+	assert.InDelta(t, expected.VX, actual.VX, 1e-9, name)
+	assert.InDelta(t, expected.VY, actual.VY, 1e-9, name)
+}
+
+func TestSolutions(t *testing.T) {
+
+	for _, p := range Problems() {
+		initial := p.State().Bodies[0]
+		assertBody(t, initial, p.Solution(0), p.Name)
+		// Undamped bound motions are periodic
+		if p.Name != "projectile" && p.Name != "damped oscillator" {
+			assertBody(t, initial, p.Solution(p.Duration), p.Name)
+		}
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+
+	convergence := Convergence{
+		Problem: "circular orbit",
+		Results: []Result{{DeltaTime: 0.02, PositionError: 0.00083, VelocityError: 0.00084}},
+		Order:   2,
+	}
+
+	var buffer bytes.Buffer
+	assert.NoError(t, WriteReport(&buffer, []Convergence{convergence}))
+	assert.Equal(t, "circular orbit: order 2.00\n  dt 0.02     position error 0.00083      velocity error 0.00084\n", buffer.String())
+}
@@ -0,0 +1,77 @@
+// Package viewport projects the world coordinates of the simulations, in
+// metres, onto the pixels of a screen, with pan and zoom.
+package viewport
+
+// Viewport shows the world around a centre point. Screen Y points down
+// unless FlipY is set.
+type Viewport struct {
+	// Size of the screen, in pixels
+	Width, Height float64
+	// World point at the centre of the screen, in metres
+	CenterX, CenterY float64
+	// Zoom of the viewport
+	PixelsPerMeter float64
+	// FlipY makes the Y axis of the world point up the screen
+	FlipY bool
+}
+
+// New returns a viewport showing the world from the origin, at the top left
+// corner of the screen, to Width and Height pixels converted into metres
+func New(width, height, pixelsPerMeter float64) Viewport {
+	return Viewport{
+		Width:          width,
+		Height:         height,
+		CenterX:        width / pixelsPerMeter / 2,
+		CenterY:        height / pixelsPerMeter / 2,
+		PixelsPerMeter: pixelsPerMeter,
+	}
+}
+
+func (v Viewport) getYSign() float64 {
+	if v.FlipY {
+		return -1
+	}
+	return 1
+}
+
+// ToScreen converts a world point into pixels
+func (v Viewport) ToScreen(x, y float64) (float64, float64) {
+	return v.Width/2 + (x-v.CenterX)*v.PixelsPerMeter,
+		v.Height/2 + v.getYSign()*(y-v.CenterY)*v.PixelsPerMeter
+}
+
+// ToWorld converts pixels, such as the position of the mouse, into a world
+// point
+func (v Viewport) ToWorld(px, py float64) (float64, float64) {
+	return v.CenterX + (px-v.Width/2)/v.PixelsPerMeter,
+		v.CenterY + v.getYSign()*(py-v.Height/2)/v.PixelsPerMeter
+}
+
+// ToPixels converts a world length, such as the radius of a body, into pixels
+func (v Viewport) ToPixels(length float64) float64 {
+	return length * v.PixelsPerMeter
+}
+
+// Bounds returns the corners of the world area shown on the screen
+func (v Viewport) Bounds() (minX, minY, maxX, maxY float64) {
+	halfWidth := v.Width / v.PixelsPerMeter / 2
+	halfHeight := v.Height / v.PixelsPerMeter / 2
+	return v.CenterX - halfWidth, v.CenterY - halfHeight, v.CenterX + halfWidth, v.CenterY + halfHeight
+}
+
+// Pan moves the view by the pixels, as when dragging the world with the mouse
+// by dx and dy
+func (v *Viewport) Pan(dx, dy float64) {
+	v.CenterX -= dx / v.PixelsPerMeter
+	v.CenterY -= v.getYSign() * dy / v.PixelsPerMeter
+}
+
+// Zoom multiplies PixelsPerMeter by the factor, keeping the world point under
+// the pixel px, py in place
+func (v *Viewport) Zoom(factor, px, py float64) {
+	x, y := v.ToWorld(px, py)
+	v.PixelsPerMeter *= factor
+	nx, ny := v.ToWorld(px, py)
+	v.CenterX += x - nx
+	v.CenterY += y - ny
+}
@@ -0,0 +1,67 @@
+package viewport
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+
+	v := New(500, 300, 10)
+
+	x, y := v.ToScreen(0, 0)
+	assert.Equal(t, []float64{0, 0}, []float64{x, y})
+	x, y = v.ToScreen(50, 30)
+	assert.Equal(t, []float64{500, 300}, []float64{x, y})
+
+	minX, minY, maxX, maxY := v.Bounds()
+	assert.Equal(t, []float64{0, 0, 50, 30}, []float64{minX, minY, maxX, maxY})
+	assert.Equal(t, 5.0, v.ToPixels(0.5))
+}
+
+func TestToWorldInvertsToScreen(t *testing.T) {
+
+	for _, flip := range []bool{false, true} {
+		v := Viewport{Width: 640, Height: 480, CenterX: -3, CenterY: 7, PixelsPerMeter: 25, FlipY: flip}
+		px, py := v.ToScreen(1.5, -2)
+		x, y := v.ToWorld(px, py)
+		assert.InDelta(t, 1.5, x, 1e-12)
+		assert.InDelta(t, -2.0, y, 1e-12)
+	}
+}
+
+func TestFlipY(t *testing.T) {
+
+	v := Viewport{Width: 100, Height: 100, PixelsPerMeter: 10, FlipY: true}
+
+	// Up in the world is up on the screen
+	_, py := v.ToScreen(0, 1)
+	assert.Equal(t, 40.0, py)
+}
+
+func TestPan(t *testing.T) {
+
+	v := New(500, 500, 10)
+
+	v.Pan(20, -10)
+
+	// The world moves with the mouse
+	x, y := v.ToScreen(0, 0)
+	assert.Equal(t, []float64{20, -10}, []float64{x, y})
+}
+
+func TestZoom(t *testing.T) {
+
+	v := New(500, 500, 10)
+	x0, y0 := v.ToWorld(100, 400)
+
+	v.Zoom(2, 100, 400)
+
+	assert.Equal(t, 20.0, v.PixelsPerMeter)
+	x, y := v.ToWorld(100, 400)
+	assert.InDelta(t, x0, x, 1e-12)
+	assert.InDelta(t, y0, y, 1e-12)
+	minX, _, maxX, _ := v.Bounds()
+	assert.InDelta(t, 25.0, maxX-minX, 1e-12)
+}
@@ -0,0 +1,51 @@
+package wave3d
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/rpagliuca/go-physics/pkg/checkpoint"
+)
+
+const CHECKPOINT_KIND = "wave3d.Grid"
+
+// WriteCheckpoint saves the grid with the simulated time and step count
+func WriteCheckpoint(w io.Writer, grid Grid, time float64, step int) error {
+	writer := checkpoint.NewWriter(w, CHECKPOINT_KIND)
+	writer.WriteFloat64(time)
+	writer.WriteInt(step)
+	writer.WriteInt(LEN)
+	for t := range grid {
+		for i := range grid[t] {
+			for j := range grid[t][i] {
+				writer.WriteFloat64(grid[t][i][j])
+			}
+		}
+	}
+	return writer.Flush()
+}
+
+// ReadCheckpoint returns the grid, the simulated time and the step count
+func ReadCheckpoint(r io.Reader) (Grid, float64, int, error) {
+	reader, err := checkpoint.NewReader(r, CHECKPOINT_KIND)
+	if err != nil {
+		return Grid{}, 0, 0, err
+	}
+	time := reader.ReadFloat64()
+	step := reader.ReadInt()
+	if length := reader.ReadInt(); reader.Err() == nil && length != LEN {
+		return Grid{}, 0, 0, fmt.Errorf("checkpoint of a grid of length %d, expected %d", length, LEN)
+	}
+	grid := Grid{}
+	for t := range grid {
+		for i := range grid[t] {
+			for j := range grid[t][i] {
+				grid[t][i][j] = reader.ReadFloat64()
+			}
+		}
+	}
+	if reader.Err() != nil {
+		return Grid{}, 0, 0, reader.Err()
+	}
+	return grid, time, step, nil
+}
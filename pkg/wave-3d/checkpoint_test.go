@@ -0,0 +1,47 @@
+package wave3d
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rpagliuca/go-physics/pkg/wave"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckpointResumesExactly(t *testing.T) {
+
+	g0 := Grid{}
+	g0[0][50][50] = 100
+	g0[1] = g0[0]
+
+	uninterrupted := g0
+	for i := 0; i < 20; i++ {
+		uninterrupted = NextStep(uninterrupted)
+	}
+
+	g := g0
+	for i := 0; i < 10; i++ {
+		g = NextStep(g)
+	}
+	var buffer bytes.Buffer
+	assert.NoError(t, WriteCheckpoint(&buffer, g, 1.5, 10))
+	restored, time, step, err := ReadCheckpoint(&buffer)
+	assert.NoError(t, err)
+	assert.Equal(t, 1.5, time)
+	assert.Equal(t, 10, step)
+	for i := 0; i < 10; i++ {
+		restored = NextStep(restored)
+	}
+
+	assert.Equal(t, uninterrupted, restored)
+}
+
+func TestCheckpointOfOtherGrid(t *testing.T) {
+
+	var buffer bytes.Buffer
+	assert.NoError(t, wave.WriteCheckpoint(&buffer, wave.Grid{}, 0, 0))
+
+	_, _, _, err := ReadCheckpoint(&buffer)
+
+	assert.EqualError(t, err, "checkpoint of wave.Grid, expected wave3d.Grid")
+}
@@ -0,0 +1,47 @@
+package wave
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/rpagliuca/go-physics/pkg/checkpoint"
+)
+
+const CHECKPOINT_KIND = "wave.Grid"
+
+// WriteCheckpoint saves the grid with the simulated time and step count
+func WriteCheckpoint(w io.Writer, grid Grid, time float64, step int) error {
+	writer := checkpoint.NewWriter(w, CHECKPOINT_KIND)
+	writer.WriteFloat64(time)
+	writer.WriteInt(step)
+	writer.WriteInt(LEN)
+	for t := range grid {
+		for i := range grid[t] {
+			writer.WriteFloat64(grid[t][i])
+		}
+	}
+	return writer.Flush()
+}
+
+// ReadCheckpoint returns the grid, the simulated time and the step count
+func ReadCheckpoint(r io.Reader) (Grid, float64, int, error) {
+	reader, err := checkpoint.NewReader(r, CHECKPOINT_KIND)
+	if err != nil {
+		return Grid{}, 0, 0, err
+	}
+	time := reader.ReadFloat64()
+	step := reader.ReadInt()
+	if length := reader.ReadInt(); reader.Err() == nil && length != LEN {
+		return Grid{}, 0, 0, fmt.Errorf("checkpoint of a grid of length %d, expected %d", length, LEN)
+	}
+	grid := Grid{}
+	for t := range grid {
+		for i := range grid[t] {
+			grid[t][i] = reader.ReadFloat64()
+		}
+	}
+	if reader.Err() != nil {
+		return Grid{}, 0, 0, reader.Err()
+	}
+	return grid, time, step, nil
+}
@@ -0,0 +1,36 @@
+package wave
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckpointResumesExactly(t *testing.T) {
+
+	g0 := Grid{}
+	g0[0][50] = 100
+	g0[1] = g0[0]
+
+	uninterrupted := g0
+	for i := 0; i < 200; i++ {
+		uninterrupted = NextStep(uninterrupted)
+	}
+
+	g := g0
+	for i := 0; i < 100; i++ {
+		g = NextStep(g)
+	}
+	var buffer bytes.Buffer
+	assert.NoError(t, WriteCheckpoint(&buffer, g, 2.5, 100))
+	restored, time, step, err := ReadCheckpoint(&buffer)
+	assert.NoError(t, err)
+	assert.Equal(t, 2.5, time)
+	assert.Equal(t, 100, step)
+	for i := 0; i < 100; i++ {
+		restored = NextStep(restored)
+	}
+
+	assert.Equal(t, uninterrupted, restored)
+}
@@ -7,15 +7,13 @@ import (
 
 func TestWave(t *testing.T) {
 
-	p0 := Position{}
-	p0[0] = 100
-	p0[1] = 90
-	p0[2] = 80
-	p0[3] = 70
+	g0 := Grid{}
+	g0[1][0] = 100
+	g0[1][1] = 90
+	g0[1][2] = 80
+	g0[1][3] = 70
 
-	s0 := State{Position: p0}
+	g1 := NextStep(g0)
 
-	s1 := NextStep(s0)
-
-	assert.NotEqual(t, s0, s1)
+	assert.NotEqual(t, g0, g1)
 }